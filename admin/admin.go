@@ -0,0 +1,271 @@
+/*
+Package admin provides an http.Handler that exposes a logger's debug state, so that debugging can be
+turned on and off on a running server. The handler is meant to be mounted on an internal port, next
+to pprof, for example:
+
+	mux.Handle("/debug/lg", admin.NewHandler(logger))
+
+GET returns the debug flag, the tags with debug mode on, the excluded tags and any pending expirations.
+The response is plain text unless the request has format=json or accepts application/json.
+
+POST turns on debug mode, DELETE turns it off. Both use the request's form values:
+
+	tag=server.db   a tag to change, can be repeated, or be a comma separated list
+	all=true        change the debug flag for the whole logger
+	for=10m         POST only, turn the change off again after the duration
+
+Posting a tag or the flag again replaces any pending expiration. A tag, or the flag, that was already on before the
+POST, other than by an earlier for, is left on and doesn't get an expiration. Changes are logged through the logger
+with the tag "lg.admin".
+*/
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sasbury/lg"
+)
+
+// allKey is the key used to track the expiration for the debug flag
+const allKey = ""
+
+// adminTags are the tags used when the handler logs a change
+var adminTags = []string{"lg.admin"}
+
+// Handler is an http.Handler for a logger's debug state
+type Handler struct {
+	sync.Mutex
+	logger   *lg.Logger
+	expiring map[string]*expiration
+}
+
+// expiration is a pending change that will turn debug off for a tag, or the whole logger
+type expiration struct {
+	timer *time.Timer
+	at    time.Time
+}
+
+// Status is the response to a GET request, and to successful POST and DELETE requests
+type Status struct {
+	Debug      bool                 `json:"debug"`
+	Tags       []string             `json:"tags"`
+	Exclusions []string             `json:"exclusions"`
+	Expiring   map[string]time.Time `json:"expiring,omitempty"`
+}
+
+// NewHandler returns a handler for the logger's debug state
+func NewHandler(logger *lg.Logger) *Handler {
+	return &Handler{
+		logger:   logger,
+		expiring: map[string]*expiration{},
+	}
+}
+
+// ServeHTTP implements http.Handler
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+	case http.MethodPost, http.MethodDelete:
+		err := h.change(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, HEAD, POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	h.writeStatus(w, r)
+}
+
+// change applies a POST or DELETE request
+func (h *Handler) change(r *http.Request) error {
+	err := r.ParseForm()
+	if err != nil {
+		return err
+	}
+
+	var tags []string
+	for _, v := range r.Form["tag"] {
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				tags = append(tags, t)
+			}
+		}
+	}
+
+	all := false
+	if v := r.Form.Get("all"); v != "" {
+		all, err = strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid all %q", v)
+		}
+	}
+
+	if !all && len(tags) == 0 {
+		return fmt.Errorf("a tag or all=true is required")
+	}
+
+	enable := r.Method == http.MethodPost
+	var expireAfter time.Duration
+	if v := r.Form.Get("for"); v != "" {
+		if !enable {
+			return fmt.Errorf("for is only supported when enabling debug mode")
+		}
+		expireAfter, err = time.ParseDuration(v)
+		if err != nil || expireAfter <= 0 {
+			return fmt.Errorf("invalid for %q", v)
+		}
+	}
+
+	h.Lock()
+	defer h.Unlock()
+
+	if all {
+		_, pending := h.expiring[allKey]
+		wasOn := h.logger.IsDebugMode() && !pending
+		h.cancel(allKey)
+		if enable {
+			h.logger.EnableDebugMode()
+			if !wasOn {
+				h.expireAfter(allKey, expireAfter)
+			}
+		} else {
+			h.logger.DisableDebugMode()
+		}
+	}
+
+	if len(tags) > 0 {
+		// tags turned on before this request, and not by an earlier for, are left on
+		wasOn := map[string]bool{}
+		for _, t := range h.logger.DebugModeTags() {
+			_, pending := h.expiring[t]
+			wasOn[t] = !pending
+		}
+		for _, t := range tags {
+			h.cancel(t)
+		}
+		if enable {
+			h.logger.EnableDebugModeFor(tags...)
+			for _, t := range tags {
+				if !wasOn[t] {
+					h.expireAfter(t, expireAfter)
+				}
+			}
+		} else {
+			h.logger.DisableDebugModeFor(tags...)
+		}
+	}
+
+	action := "disabled"
+	if enable {
+		action = "enabled"
+	}
+	if expireAfter > 0 {
+		action += fmt.Sprintf(" for %v", expireAfter)
+	}
+	h.logger.TagPrintf(adminTags, "debug mode %s, all: %v, tags: %v, from %s", action, all, tags, r.RemoteAddr)
+
+	return nil
+}
+
+// cancel stops a pending expiration, assumes the lock is held
+func (h *Handler) cancel(key string) {
+	if e, ok := h.expiring[key]; ok {
+		e.timer.Stop()
+		delete(h.expiring, key)
+	}
+}
+
+// expireAfter turns debug mode off for the key after the duration, a zero duration does nothing.
+// Assumes the lock is held.
+func (h *Handler) expireAfter(key string, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+
+	e := &expiration{
+		at: time.Now().Add(d),
+	}
+	e.timer = time.AfterFunc(d, func() {
+		h.expire(key, e)
+	})
+	h.expiring[key] = e
+}
+
+// expire is called by an expiration's timer
+func (h *Handler) expire(key string, e *expiration) {
+	h.Lock()
+	defer h.Unlock()
+
+	if h.expiring[key] != e {
+		return // replaced or cancelled after the timer fired
+	}
+	delete(h.expiring, key)
+
+	if key == allKey {
+		h.logger.DisableDebugMode()
+		h.logger.TagPrintf(adminTags, "debug mode expired, all: true")
+	} else {
+		h.logger.DisableDebugModeFor(key)
+		h.logger.TagPrintf(adminTags, "debug mode expired, tags: [%s]", key)
+	}
+}
+
+// Status returns the logger's current debug state and pending expirations
+func (h *Handler) Status() Status {
+	h.Lock()
+	defer h.Unlock()
+
+	status := Status{
+		Debug:      h.logger.IsDebugMode(),
+		Tags:       h.logger.DebugModeTags(),
+		Exclusions: h.logger.DebugModeExclusions(),
+	}
+
+	if len(h.expiring) > 0 {
+		status.Expiring = make(map[string]time.Time, len(h.expiring))
+		for k, e := range h.expiring {
+			if k == allKey {
+				k = "*all*"
+			}
+			status.Expiring[k] = e.at
+		}
+	}
+
+	return status
+}
+
+// writeStatus writes the status as JSON or text, depending on the request
+func (h *Handler) writeStatus(w http.ResponseWriter, r *http.Request) {
+	status := h.Status()
+
+	if r.URL.Query().Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintf(w, "debug: %v\n", status.Debug)
+	fmt.Fprintf(w, "tags: %s\n", strings.Join(status.Tags, ", "))
+	fmt.Fprintf(w, "exclusions: %s\n", strings.Join(status.Exclusions, ", "))
+
+	keys := make([]string, 0, len(status.Expiring))
+	for k := range status.Expiring {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(w, "expiring: %s at %s\n", k, status.Expiring[k].Format(time.RFC3339))
+	}
+}
@@ -0,0 +1,157 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sasbury/lg"
+	"github.com/stretchr/testify/require"
+)
+
+func do(t *testing.T, h http.Handler, method string, query string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, "/debug/lg?"+query, nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func decode(t *testing.T, w *httptest.ResponseRecorder) Status {
+	var status Status
+	require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+	return status
+}
+
+func TestHandlerGet(t *testing.T) {
+	logger := lg.NewLogger()
+	logger.EnableDebugModeFor("red", "blue")
+	logger.ExcludeDebugModeFor("heartbeat")
+	h := NewHandler(logger)
+
+	w := do(t, h, http.MethodGet, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "debug: false\ntags: red, blue\nexclusions: heartbeat\n", w.Body.String())
+
+	w = do(t, h, http.MethodGet, "format=json")
+	status := decode(t, w)
+	require.False(t, status.Debug)
+	require.Equal(t, []string{"red", "blue"}, status.Tags)
+	require.Equal(t, []string{"heartbeat"}, status.Exclusions)
+
+	r := httptest.NewRequest(http.MethodGet, "/debug/lg", nil)
+	r.Header.Set("Accept", "application/json")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	decode(t, w)
+
+	w = do(t, h, http.MethodPut, "")
+	require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestHandlerEnableDisable(t *testing.T) {
+	a := &lg.ArrayAppender{}
+	logger := lg.NewLoggerWithConfig(lg.MinimalFormat, a.Log)
+	h := NewHandler(logger)
+
+	w := do(t, h, http.MethodPost, "tag=red&tag=green,blue&format=json")
+	require.Equal(t, http.StatusOK, w.Code)
+	status := decode(t, w)
+	require.Equal(t, []string{"red", "green", "blue"}, status.Tags)
+	require.True(t, logger.IsDebugModeFor("green"))
+	require.Len(t, a.Entries, 1)
+	require.True(t, strings.Contains(a.Entries[0], "debug mode enabled"))
+
+	do(t, h, http.MethodPost, "all=true")
+	require.True(t, logger.IsDebugMode())
+
+	w = do(t, h, http.MethodDelete, "tag=green&all=1")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.False(t, logger.IsDebugMode())
+	require.False(t, logger.IsDebugModeFor("green"))
+	require.True(t, logger.IsDebugModeFor("red"))
+
+	// form bodies work too
+	r := httptest.NewRequest(http.MethodPost, "/debug/lg", strings.NewReader(url.Values{"tag": {"yellow"}}.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.True(t, logger.IsDebugModeFor("yellow"))
+}
+
+func TestHandlerErrors(t *testing.T) {
+	logger := lg.NewLoggerWithConfig(lg.MinimalFormat, lg.NullAppender)
+	h := NewHandler(logger)
+
+	bad := []struct {
+		method string
+		query  string
+	}{
+		{http.MethodPost, ""},
+		{http.MethodPost, "all=maybe"},
+		{http.MethodPost, "tag=red&for=soon"},
+		{http.MethodPost, "tag=red&for=-1s"},
+		{http.MethodDelete, "tag=red&for=1s"},
+		{http.MethodDelete, "tag=,"},
+	}
+
+	for _, b := range bad {
+		w := do(t, h, b.method, b.query)
+		require.Equal(t, http.StatusBadRequest, w.Code, b.query)
+	}
+	require.Empty(t, logger.DebugModeTags())
+}
+
+func TestHandlerExpiry(t *testing.T) {
+	logger := lg.NewLoggerWithConfig(lg.MinimalFormat, lg.NullAppender)
+	h := NewHandler(logger)
+
+	w := do(t, h, http.MethodPost, "tag=red&all=true&for=20ms&format=json")
+	status := decode(t, w)
+	require.Len(t, status.Expiring, 2)
+	require.Contains(t, status.Expiring, "red")
+	require.Contains(t, status.Expiring, "*all*")
+
+	w = do(t, h, http.MethodGet, "")
+	require.True(t, strings.Contains(w.Body.String(), "expiring: red at"))
+
+	do(t, h, http.MethodPost, "tag=blue&for=20ms")
+	do(t, h, http.MethodPost, "tag=blue") // replaces the expiration
+
+	require.Eventually(t, func() bool {
+		return !logger.IsDebugMode() && !logger.IsDebugModeFor("red")
+	}, time.Second, 5*time.Millisecond)
+
+	time.Sleep(40 * time.Millisecond)
+	require.True(t, logger.IsDebugModeFor("blue"))
+	require.Empty(t, h.Status().Expiring)
+
+	do(t, h, http.MethodPost, "tag=green&for=10ms")
+	do(t, h, http.MethodDelete, "tag=green")
+	require.Empty(t, h.Status().Expiring)
+}
+
+func TestHandlerExpiryLeavesEarlierDebugOn(t *testing.T) {
+	logger := lg.NewLoggerWithConfig(lg.MinimalFormat, lg.NullAppender)
+	logger.EnableDebugMode()
+	logger.EnableDebugModeFor("server.db")
+	h := NewHandler(logger)
+
+	w := do(t, h, http.MethodPost, "tag=server.db,red&all=true&for=20ms&format=json")
+	status := decode(t, w)
+	require.Len(t, status.Expiring, 1)
+	require.Contains(t, status.Expiring, "red")
+
+	require.Eventually(t, func() bool {
+		return len(h.Status().Expiring) == 0
+	}, time.Second, 5*time.Millisecond)
+
+	time.Sleep(40 * time.Millisecond)
+	require.True(t, logger.IsDebugMode())
+	require.Equal(t, []string{"server.db"}, logger.DebugModeTags())
+}
@@ -0,0 +1,30 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/sasbury/lg"
+)
+
+// DefaultDebugHeader is the header used by ForceDebugMiddleware when no header is given
+const DefaultDebugHeader = "X-Lg-Debug"
+
+// ForceDebugMiddleware marks the context of requests with the header set to a true value, like "1" or "true",
+// with lg.ContextWithForceDebug, so that loggers from lg.FromContext, and the Ctx methods, print debug entries for
+// that request only. An empty header uses DefaultDebugHeader.
+// Anyone who can set the header can turn on debug output for their requests, so the header should be stripped
+// from untrusted traffic, by a proxy for example.
+func ForceDebugMiddleware(header string, next http.Handler) http.Handler {
+	if header == "" {
+		header = DefaultDebugHeader
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		force, err := strconv.ParseBool(r.Header.Get(header))
+		if err == nil && force {
+			r = r.WithContext(lg.ContextWithForceDebug(r.Context()))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,56 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sasbury/lg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForceDebugMiddleware(t *testing.T) {
+	a := &lg.EntryArrayAppender{}
+	logger := lg.NewLoggerWithEntryAppender(a.Append)
+
+	handler := ForceDebugMiddleware("", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := lg.NewContext(r.Context(), logger)
+		lg.FromContext(ctx).Debugf("debug %s", r.URL.Path)
+		logger.DebugfCtx(r.Context(), "ctx %s", r.URL.Path)
+	}))
+
+	for _, value := range []string{"", "false", "junk"} {
+		r := httptest.NewRequest(http.MethodGet, "/off", nil)
+		if value != "" {
+			r.Header.Set(DefaultDebugHeader, value)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	require.Empty(t, a.Entries)
+
+	r := httptest.NewRequest(http.MethodGet, "/on", nil)
+	r.Header.Set(DefaultDebugHeader, "1")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	require.Len(t, a.Entries, 2)
+	require.Equal(t, "debug /on", a.Entries[0].Message())
+	require.Equal(t, "ctx /on", a.Entries[1].Message())
+	require.True(t, a.Entries[0].Debug)
+	require.False(t, logger.IsDebugMode())
+}
+
+func TestForceDebugMiddlewareHeader(t *testing.T) {
+	var forced bool
+	handler := ForceDebugMiddleware("X-Trace", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forced = lg.IsForceDebugContext(r.Context())
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(DefaultDebugHeader, "true")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	require.False(t, forced)
+
+	r.Header.Set("X-Trace", "true")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	require.True(t, forced)
+}
@@ -0,0 +1,88 @@
+package lg
+
+import (
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// logWrapper is used to test the caller skip, it adds a frame between the caller and the logger
+func logWrapper(logger *Logger, message string) {
+	logger.Printf(message)
+}
+
+func TestCallerCapture(t *testing.T) {
+	a := &EntryArrayAppender{}
+	logger := NewLoggerWithEntryAppender(a.Append)
+
+	logger.Printf("no caller")
+	require.Nil(t, a.Entries[0].Caller)
+
+	logger.EnableCallerCapture(0)
+	logger.Printf("one")
+	logger.EnableDebugMode()
+	logger.WithTags("red").Debugf("two")
+	logger.TagDebugf([]string{"red"}, "three")
+	logger.TagPrintf(nil, "four")
+	stdLogger := log.New(logger, "", 0)
+	stdLogger.Print("five")
+
+	require.Len(t, a.Entries, 6)
+	for i, line := range []int{24, 26, 27, 28} {
+		caller := a.Entries[i+1].Caller
+		require.NotNil(t, caller)
+		require.True(t, strings.HasSuffix(caller.File, "caller_test.go"), caller.File)
+		require.Equal(t, line, caller.Line)
+		require.Equal(t, "github.com/sasbury/lg.TestCallerCapture", caller.Function)
+	}
+	require.Equal(t, "log.(*Logger).output", a.Entries[5].Caller.Function)
+
+	logger.EnableCallerCapture(1)
+	logWrapper(logger, "six")
+	require.Equal(t, 43, a.Entries[6].Caller.Line)
+	require.True(t, strings.HasSuffix(a.Entries[6].Caller.String(), "/caller_test.go:43"))
+
+	logger.DisableCallerCapture()
+	logger.Printf("seven")
+	require.Nil(t, a.Entries[7].Caller)
+}
+
+func TestCallerOnlyAfterDebugCheck(t *testing.T) {
+	a := &EntryArrayAppender{}
+	logger := NewLoggerWithEntryAppender(a.Append)
+	logger.EnableCallerCapture(0)
+
+	logger.Debugf("one")
+	logger.TagDebugf([]string{"red"}, "two")
+	require.Empty(t, a.Entries)
+}
+
+func TestFullFormatCaller(t *testing.T) {
+	a := &ArrayAppender{}
+	logger := NewLoggerWithConfig(FullFormat, a.Log)
+	logger.EnableCallerCapture(0)
+
+	logger.With("user", "bob").TagPrintf([]string{"red"}, "one %s", "formatted")
+	logger.Printf("100%%")
+
+	require.True(t, strings.HasSuffix(a.Entries[0], "/caller_test.go:67 [red] one formatted user=bob"), a.Entries[0])
+	require.True(t, strings.HasSuffix(a.Entries[1], "/caller_test.go:68 100%"), a.Entries[1])
+
+	logger.Configure(SimpleFormat, a.Log)
+	logger.Printf("two")
+	require.False(t, strings.Contains(a.Entries[2], "caller_test.go"))
+}
+
+func BenchmarkDebugWithCallerCapture(b *testing.B) {
+	b.ReportAllocs()
+	logger := NewLogger()
+	logger.Configure(MinimalFormat, NullAppender)
+	logger.EnableDebugMode()
+	logger.EnableCallerCapture(0)
+
+	for n := 0; n < b.N; n++ {
+		logger.Debugf("one %s", "formatted")
+	}
+}
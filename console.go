@@ -0,0 +1,137 @@
+package lg
+
+import (
+	"fmt"
+	"hash/fnv"
+	"os"
+	"strings"
+	"time"
+)
+
+// The ANSI escape codes used by ColorFormat
+const (
+	colorReset = "\x1b[0m"
+	colorDim   = "\x1b[2m"
+	colorGrey  = "\x1b[90m"
+	colorInfo  = "\x1b[32m"
+	colorDebug = "\x1b[2;36m"
+)
+
+// tagColors are the colors used for tags, a tag always gets the same color
+var tagColors = []string{
+	"\x1b[31m",
+	"\x1b[32m",
+	"\x1b[33m",
+	"\x1b[34m",
+	"\x1b[35m",
+	"\x1b[36m",
+	"\x1b[91m",
+	"\x1b[92m",
+	"\x1b[93m",
+	"\x1b[94m",
+	"\x1b[95m",
+	"\x1b[96m",
+}
+
+// ColorFormat is FullFormat with ANSI colors, the time and caller are grey, tags are colored by a hash of the tag
+// so that each tag always gets the same color, and debug entries are dimmed. It always adds colors, use
+// NewConsoleFormatter to only add them for terminals.
+func ColorFormat(debug bool, tags []string, t time.Time, format string, args ...interface{}) string {
+	return colorFormat(DefaultTimeFormat, debug, tags, t, format, args)
+}
+
+// NewColorFormatter returns ColorFormat with the time formatted by the TimeFormatter, nil uses DefaultTimeFormat
+func NewColorFormatter(timeFormat TimeFormatter) LogFormatter {
+	timeFormat = timeFormat.orDefault()
+	return func(debug bool, tags []string, t time.Time, format string, args ...interface{}) string {
+		return colorFormat(timeFormat, debug, tags, t, format, args)
+	}
+}
+
+func colorFormat(timeFormat TimeFormatter, debug bool, tags []string, t time.Time, format string, args []interface{}) string {
+	format, args, entry := SplitEntry(format, args)
+
+	b := &strings.Builder{}
+	b.WriteString(colorGrey)
+	b.WriteString(timeFormat(t))
+	b.WriteString(colorReset)
+
+	if debug {
+		b.WriteString(" " + colorDebug + "[DBG]" + colorReset)
+	} else {
+		b.WriteString(" " + colorInfo + "[INF]" + colorReset)
+	}
+
+	if entry != nil && entry.Caller != nil {
+		b.WriteString(" " + colorGrey)
+		b.WriteString(entry.Caller.String())
+		b.WriteString(colorReset)
+	}
+
+	if len(tags) > 0 {
+		b.WriteString(" [")
+		for i, tag := range tags {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(tagColor(tag))
+			b.WriteString(tag)
+			b.WriteString(colorReset)
+		}
+		b.WriteString("]")
+	}
+
+	b.WriteString(" ")
+	if debug {
+		b.WriteString(colorDim)
+	}
+	b.WriteString(fmt.Sprintf(format, args...))
+	if entry != nil {
+		b.WriteString(fmt.Sprint(entryArg{entry: entry}))
+	}
+	if debug {
+		b.WriteString(colorReset)
+	}
+
+	return b.String()
+}
+
+// tagColor returns the color for a tag
+func tagColor(tag string) string {
+	h := fnv.New32a()
+	h.Write([]byte(tag))
+	return tagColors[h.Sum32()%uint32(len(tagColors))]
+}
+
+// NewConsoleFormatter returns ColorFormat if ColorEnabled returns true for the file, and FullFormat if it doesn't,
+// with the time formatted by the TimeFormatter, nil uses DefaultTimeFormat. The file should be the one the appender
+// writes to, os.Stderr for StdErrAppender and os.Stdout for StdOutAppender.
+func NewConsoleFormatter(f *os.File, timeFormat TimeFormatter) LogFormatter {
+	if ColorEnabled(f) {
+		if timeFormat == nil {
+			return ColorFormat
+		}
+		return NewColorFormatter(timeFormat)
+	}
+	if timeFormat == nil {
+		return FullFormat
+	}
+	return NewFullFormatter(timeFormat)
+}
+
+// ColorEnabled returns true if output to the file should be colored. NO_COLOR, set to anything other than an
+// empty string, turns color off and FORCE_COLOR, set to anything other than an empty string, "0" or "false",
+// turns it on. Otherwise color is on if the file is a terminal.
+func ColorEnabled(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+
+	switch strings.ToLower(os.Getenv("FORCE_COLOR")) {
+	case "", "0", "false":
+	default:
+		return true
+	}
+
+	return IsTerminal(f)
+}
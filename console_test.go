@@ -0,0 +1,106 @@
+package lg
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestColorFormat(t *testing.T) {
+	now := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	require.Equal(t, "\x1b[90mJan  2 15:04:05.000\x1b[0m \x1b[32m[INF]\x1b[0m hello world",
+		ColorFormat(false, nil, now, "hello %s", "world"))
+
+	red := tagColor("red")
+	require.Equal(t, red, tagColor("red"))
+	require.Equal(t, "\x1b[90mJan  2 15:04:05.000\x1b[0m \x1b[2;36m[DBG]\x1b[0m ["+red+"red\x1b[0m, "+tagColor("blue")+"blue\x1b[0m] \x1b[2mhello\x1b[0m",
+		ColorFormat(true, []string{"red", "blue"}, now, "hello"))
+
+	entry := &Entry{
+		Time:   now,
+		Format: "hello",
+		Fields: Fields{String("k", "v")},
+		Caller: &Caller{File: "/src/server/main.go", Line: 12},
+	}
+	require.Equal(t, "\x1b[90mJan  2 15:04:05.000\x1b[0m \x1b[32m[INF]\x1b[0m \x1b[90mserver/main.go:12\x1b[0m hello k=v",
+		entry.FormatWith(ColorFormat))
+}
+
+func TestColorEnabled(t *testing.T) {
+	file, err := os.Create(filepath.Join(t.TempDir(), "out.log"))
+	require.NoError(t, err)
+	defer file.Close()
+
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("FORCE_COLOR", "")
+	require.False(t, IsTerminal(file))
+	require.False(t, IsTerminal(nil))
+	require.False(t, ColorEnabled(file))
+
+	t.Setenv("FORCE_COLOR", "1")
+	require.True(t, ColorEnabled(file))
+	now := time.Now()
+	require.Equal(t, ColorFormat(false, nil, now, "x"), NewConsoleFormatter(file, nil)(false, nil, now, "x"))
+
+	t.Setenv("FORCE_COLOR", "false")
+	require.False(t, ColorEnabled(file))
+	require.Equal(t, FullFormat(false, nil, now, "x"), NewConsoleFormatter(file, nil)(false, nil, now, "x"))
+
+	t.Setenv("FORCE_COLOR", "1")
+	t.Setenv("NO_COLOR", "1")
+	require.False(t, ColorEnabled(file))
+}
+
+func TestConsoleFormatterByName(t *testing.T) {
+	now := time.Now()
+
+	// the environment is checked when the formatter is asked for, not when the package is loaded
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("FORCE_COLOR", "1")
+	formatter, err := FormatterByName("Console")
+	require.NoError(t, err)
+	require.Equal(t, ColorFormat(false, nil, now, "x"), formatter(false, nil, now, "x"))
+
+	t.Setenv("FORCE_COLOR", "")
+	formatter, err = FormatterByNameFor("console", nil)
+	require.NoError(t, err)
+	require.Equal(t, FullFormat(false, nil, now, "x"), formatter(false, nil, now, "x"))
+
+	_, err = FormatterByNameFor("fancy", os.Stdout)
+	require.EqualError(t, err, `unknown formatter "fancy"`)
+
+	logger := NewLogger()
+	require.Equal(t, os.Stdout, logger.outputFile("stdout"))
+	require.Equal(t, os.Stderr, logger.outputFile("stderr"))
+	require.Equal(t, os.Stderr, logger.outputFile(""))
+	require.Nil(t, logger.outputFile("file:/tmp/out.log"))
+}
+
+func TestIsTerminal(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("uses /dev/ptmx")
+	}
+
+	pty, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+	if err != nil {
+		t.Skip("no pseudo terminals:", err)
+	}
+	defer pty.Close()
+
+	require.True(t, IsTerminal(pty))
+
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("FORCE_COLOR", "")
+	require.True(t, ColorEnabled(pty))
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer r.Close()
+	defer w.Close()
+	require.False(t, IsTerminal(w))
+}
@@ -0,0 +1,127 @@
+package lg
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// contextKey is the key for the contextValues stored in a context
+type contextKey struct{}
+
+// contextValues holds the logger, tags and fields stored in a context, it is copied whenever one of them changes
+type contextValues struct {
+	logger     *Logger
+	tags       []string
+	fields     Fields
+	forceDebug bool
+}
+
+// defaultLogger is returned by FromContext for contexts without a logger
+var defaultLogger atomic.Pointer[Logger]
+
+func init() {
+	defaultLogger.Store(NewLogger())
+}
+
+// DefaultLogger returns the logger used by FromContext when a context doesn't have one,
+// it prints to stderr with the simple formatter unless it is replaced with SetDefaultLogger
+func DefaultLogger() *Logger {
+	return defaultLogger.Load()
+}
+
+// SetDefaultLogger replaces the logger used by FromContext when a context doesn't have one, a nil logger
+// restores a new stderr logger
+func SetDefaultLogger(l *Logger) {
+	if l == nil {
+		l = NewLogger()
+	}
+	defaultLogger.Store(l)
+}
+
+// contextValuesFrom returns the values stored in the context, or empty values
+func contextValuesFrom(ctx context.Context) contextValues {
+	if ctx == nil {
+		return contextValues{}
+	}
+	values, _ := ctx.Value(contextKey{}).(contextValues)
+	return values
+}
+
+// NewContext returns a copy of ctx that carries the logger, tags and fields already stored in ctx are kept
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	values := contextValuesFrom(ctx)
+	values.logger = l
+	return context.WithValue(ctx, contextKey{}, values)
+}
+
+// ContextWithTags returns a copy of ctx with the tags added to any tags it already carries.
+// The tags are added to entries printed by the logger returned from FromContext and by the Ctx methods.
+func ContextWithTags(ctx context.Context, tags ...string) context.Context {
+	values := contextValuesFrom(ctx)
+	values.tags = mergeTags(values.tags, tags)
+	return context.WithValue(ctx, contextKey{}, values)
+}
+
+// ContextWith returns a copy of ctx with the fields added to any fields it already carries, the arguments
+// are the same as for With. Middleware can use this to add a request id once, so that every entry printed
+// for the request carries it.
+func ContextWith(ctx context.Context, kv ...interface{}) context.Context {
+	values := contextValuesFrom(ctx)
+	values.fields = values.fields.merge(toFields(kv))
+	return context.WithValue(ctx, contextKey{}, values)
+}
+
+// ContextWithForceDebug returns a copy of ctx that makes the logger returned from FromContext, and the Ctx methods,
+// print debug entries regardless of the debug state, see WithForceDebug
+func ContextWithForceDebug(ctx context.Context) context.Context {
+	values := contextValuesFrom(ctx)
+	values.forceDebug = true
+	return context.WithValue(ctx, contextKey{}, values)
+}
+
+// IsForceDebugContext returns true if ctx was created with ContextWithForceDebug
+func IsForceDebugContext(ctx context.Context) bool {
+	return contextValuesFrom(ctx).forceDebug
+}
+
+// FromContext returns the logger stored in ctx, or the default logger, with the tags and fields stored in ctx added.
+// If ctx forces debug, so does the returned logger.
+func FromContext(ctx context.Context) *Logger {
+	values := contextValuesFrom(ctx)
+	l := values.logger
+	if l == nil {
+		l = DefaultLogger()
+	}
+	return l.withContextValues(values)
+}
+
+// withContextValues returns the logger, or a child with the context's tags, fields and force debug marker if it has any
+func (l *Logger) withContextValues(values contextValues) *Logger {
+	if len(values.tags) == 0 && len(values.fields) == 0 && !values.forceDebug {
+		return l
+	}
+	return l.child(l.fields.merge(values.fields), mergeTags(l.tags, values.tags), l.forceDebug || values.forceDebug)
+}
+
+// PrintfCtx is Printf with the tags and fields stored in ctx added to the entry, the logger stored in ctx is not used
+func (l *Logger) PrintfCtx(ctx context.Context, fmt string, args ...interface{}) error {
+	if l == nil {
+		return nil
+	}
+	l = l.withContextValues(contextValuesFrom(ctx))
+	return l.output(1, false, noStack, l.tags, fmt, args)
+}
+
+// DebugfCtx is Debugf with the tags and fields stored in ctx added to the entry, debug is on if it is on for any
+// of the logger's or the context's tags, or if ctx forces debug. The logger stored in ctx is not used.
+func (l *Logger) DebugfCtx(ctx context.Context, fmt string, args ...interface{}) error {
+	if l == nil {
+		return nil
+	}
+	values := contextValuesFrom(ctx)
+	c := l.config()
+	if !l.forceDebug && !values.forceDebug && !c.loadDebug().isOnForBoth(l.tags, values.tags) && c.suppressed.Load() == nil {
+		return nil // nothing will be printed or recorded, so the child isn't needed
+	}
+	return l.withContextValues(values).debugf(1, fmt, args)
+}
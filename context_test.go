@@ -0,0 +1,176 @@
+package lg
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromContext(t *testing.T) {
+	require.Equal(t, DefaultLogger(), FromContext(context.Background()))
+	require.Equal(t, DefaultLogger(), FromContext(nil))
+
+	a := &EntryArrayAppender{}
+	logger := NewLoggerWithEntryAppender(a.Append).WithTags("server")
+
+	ctx := NewContext(context.Background(), logger)
+	require.Equal(t, logger, FromContext(ctx))
+
+	ctx = ContextWith(ctx, "request_id", "abc")
+	ctx = ContextWithTags(ctx, "http")
+	FromContext(ctx).Printf("one")
+
+	require.Len(t, a.Entries, 1)
+	require.Equal(t, []string{"server", "http"}, a.Entries[0].Tags)
+	require.Equal(t, Fields{String("request_id", "abc")}, a.Entries[0].Fields)
+
+	// tags and fields added before the logger are kept
+	ctx = ContextWith(context.Background(), "user", "bob")
+	ctx = NewContext(ctx, logger)
+	FromContext(ctx).Printf("two")
+	require.Equal(t, Fields{String("user", "bob")}, a.Entries[1].Fields)
+	require.Equal(t, []string{"server"}, a.Entries[1].Tags)
+}
+
+func TestSetDefaultLogger(t *testing.T) {
+	previous := DefaultLogger()
+	defer SetDefaultLogger(previous)
+
+	a := &EntryArrayAppender{}
+	logger := NewLoggerWithEntryAppender(a.Append)
+	SetDefaultLogger(logger)
+
+	ctx := ContextWith(context.Background(), "request_id", "abc")
+	FromContext(ctx).Printf("one")
+	require.Len(t, a.Entries, 1)
+	require.Equal(t, Fields{String("request_id", "abc")}, a.Entries[0].Fields)
+
+	SetDefaultLogger(nil)
+	require.NotNil(t, DefaultLogger())
+	require.NotEqual(t, logger, DefaultLogger())
+}
+
+func TestContextMethods(t *testing.T) {
+	a := &EntryArrayAppender{}
+	logger := NewLoggerWithEntryAppender(a.Append).With("service", "api")
+
+	ctx := ContextWith(context.Background(), "request_id", "abc", "service", "web")
+	ctx = ContextWithTags(ctx, "db")
+
+	logger.PrintfCtx(ctx, "one %d", 1)
+	require.Len(t, a.Entries, 1)
+	require.Equal(t, "one 1", a.Entries[0].Message())
+	require.Equal(t, []string{"db"}, a.Entries[0].Tags)
+	require.Equal(t, Fields{String("service", "web"), String("request_id", "abc")}, a.Entries[0].Fields)
+
+	logger.DebugfCtx(ctx, "two")
+	require.Len(t, a.Entries, 1)
+
+	logger.EnableDebugModeFor("db")
+	logger.DebugfCtx(ctx, "three")
+	logger.DebugfCtx(context.Background(), "four")
+	require.Len(t, a.Entries, 2)
+	require.True(t, a.Entries[1].Debug)
+	require.Equal(t, "three", a.Entries[1].Message())
+
+	logger.PrintfCtx(context.Background(), "five")
+	require.Equal(t, Fields{String("service", "api")}, a.Entries[2].Fields)
+
+	var nilLogger *Logger
+	require.NoError(t, nilLogger.PrintfCtx(ctx, "nil"))
+	require.NoError(t, nilLogger.DebugfCtx(ctx, "nil"))
+}
+
+func TestContextMethodsDebugChecks(t *testing.T) {
+	a := &EntryArrayAppender{}
+	suppressed := &EntryArrayAppender{}
+	logger := NewLoggerWithEntryAppender(a.Append).WithTags("server")
+	logger.EnableDebugModeFor("db")
+	logger.ExcludeDebugModeFor("noisy")
+
+	ctx := ContextWith(context.Background(), "request_id", "abc")
+	logger.DebugfCtx(ContextWithTags(ctx, "db", "noisy"), "one")
+	logger.DebugfCtx(ctx, "two")
+	require.Empty(t, a.Entries)
+
+	logger.SetSuppressedDebugAppender(suppressed.Append)
+	logger.DebugfCtx(ContextWithTags(ctx, "noisy"), "three")
+	logger.DebugfCtx(ContextWithTags(ctx, "db"), "four")
+	require.Len(t, suppressed.Entries, 1)
+	require.Equal(t, "three", suppressed.Entries[0].Message())
+	require.Equal(t, []string{"server", "noisy"}, suppressed.Entries[0].Tags)
+	require.Equal(t, Fields{String("request_id", "abc")}, suppressed.Entries[0].Fields)
+	require.Len(t, a.Entries, 1)
+	require.Equal(t, "four", a.Entries[0].Message())
+	require.Equal(t, []string{"server", "db"}, a.Entries[0].Tags)
+}
+
+func TestContextMethodsCaller(t *testing.T) {
+	a := &EntryArrayAppender{}
+	logger := NewLoggerWithEntryAppender(a.Append)
+	logger.EnableCallerCapture(0)
+	logger.EnableDebugMode()
+
+	ctx := ContextWithTags(context.Background(), "db")
+	logger.PrintfCtx(ctx, "one")
+	logger.DebugfCtx(ctx, "two")
+	logger.DebugfCtx(context.Background(), "three")
+
+	require.Len(t, a.Entries, 3)
+	for _, entry := range a.Entries {
+		require.True(t, strings.HasSuffix(entry.Caller.File, "context_test.go"), entry.Caller.File)
+		require.Equal(t, "github.com/sasbury/lg.TestContextMethodsCaller", entry.Caller.Function)
+	}
+}
+
+func TestForceDebug(t *testing.T) {
+	a := &EntryArrayAppender{}
+	logger := NewLoggerWithEntryAppender(a.Append)
+	logger.ExcludeDebugModeFor("noisy")
+
+	forced := logger.WithForceDebug()
+	require.True(t, forced.IsForceDebug())
+	require.False(t, logger.IsForceDebug())
+	require.True(t, forced.With("k", "v").WithTags("red").IsForceDebug())
+
+	logger.Debugf("one")
+	logger.TagDebugf([]string{"red"}, "two")
+	require.Empty(t, a.Entries)
+
+	forced.Debugf("three")
+	forced.TagDebugf([]string{"noisy"}, "four")
+	forced.WithTags("red").Debugf("five")
+	require.Len(t, a.Entries, 3)
+	require.Equal(t, []string{"noisy"}, a.Entries[1].Tags)
+	require.Equal(t, []string{"red"}, a.Entries[2].Tags)
+	for _, entry := range a.Entries {
+		require.True(t, entry.Debug)
+	}
+
+	ctx := ContextWithForceDebug(context.Background())
+	require.True(t, IsForceDebugContext(ctx))
+	require.False(t, IsForceDebugContext(context.Background()))
+
+	logger.DebugfCtx(ctx, "six")
+	FromContext(NewContext(ctx, logger)).Debugf("seven")
+	logger.DebugfCtx(context.Background(), "eight")
+	require.Len(t, a.Entries, 5)
+	require.Equal(t, "seven", a.Entries[4].Message())
+
+	var nilLogger *Logger
+	require.Nil(t, nilLogger.WithForceDebug())
+	require.False(t, nilLogger.IsForceDebug())
+}
+
+func BenchmarkDebugfCtxWithDebugOff(b *testing.B) {
+	b.ReportAllocs()
+	logger := NewLogger()
+	logger.Configure(MinimalFormat, NullAppender)
+	ctx := ContextWithTags(ContextWith(context.Background(), "request_id", "abc"), "http")
+
+	for n := 0; n < b.N; n++ {
+		logger.DebugfCtx(ctx, "one formatted")
+	}
+}
@@ -0,0 +1,131 @@
+package lg
+
+import "strings"
+
+// debugState is an immutable snapshot of a logger's debug configuration. Changes to the configuration
+// publish a new snapshot, so checking if debug is on never requires the logger's lock.
+type debugState struct {
+	debug    bool
+	enabled  tagSet
+	excluded tagSet
+}
+
+// isOnFor returns true if debug is on for a call with the tags
+func (s *debugState) isOnFor(tags []string) bool {
+	if !s.debug && s.enabled.empty() {
+		return false
+	}
+	return (s.debug || s.enabled.matchesAny(tags)) && !s.excluded.matchesAny(tags)
+}
+
+// isOnForBoth returns true if debug is on for a call with the tags in both lists, without merging them
+func (s *debugState) isOnForBoth(first []string, second []string) bool {
+	if !s.debug && s.enabled.empty() {
+		return false
+	}
+	return (s.debug || s.enabled.matchesAny(first) || s.enabled.matchesAny(second)) &&
+		!s.excluded.matchesAny(first) && !s.excluded.matchesAny(second)
+}
+
+// tagSet is an immutable set of debug tags. Plain tags are kept in a map so that a tag, and
+// each of its ancestors, can be checked in constant time, only tags with wildcards are matched linearly.
+type tagSet struct {
+	tags     []string
+	exact    map[string]struct{}
+	patterns []string
+}
+
+// newTagSet creates a set from the tags, duplicates are ignored
+func newTagSet(tags []string) tagSet {
+	ts := tagSet{
+		tags:  make([]string, 0, len(tags)),
+		exact: make(map[string]struct{}, len(tags)),
+	}
+
+	seen := make(map[string]struct{}, len(tags))
+	for _, t := range tags {
+		if _, ok := seen[t]; ok {
+			continue
+		}
+		seen[t] = struct{}{}
+		ts.tags = append(ts.tags, t)
+
+		if isTagPattern(t) {
+			ts.patterns = append(ts.patterns, t)
+		} else {
+			ts.exact[t] = struct{}{}
+		}
+	}
+
+	return ts
+}
+
+// with returns a new set containing the tags in this set and the new ones
+func (ts tagSet) with(tags []string) tagSet {
+	all := make([]string, 0, len(ts.tags)+len(tags))
+	all = append(all, ts.tags...)
+	return newTagSet(append(all, tags...))
+}
+
+// without returns a new set containing the tags in this set that aren't in the list
+func (ts tagSet) without(tags []string) tagSet {
+	remove := make(map[string]struct{}, len(tags))
+	for _, t := range tags {
+		remove[t] = struct{}{}
+	}
+
+	kept := make([]string, 0, len(ts.tags))
+	for _, t := range ts.tags {
+		if _, ok := remove[t]; !ok {
+			kept = append(kept, t)
+		}
+	}
+
+	return newTagSet(kept)
+}
+
+// list returns a copy of the tags in the order they were added
+func (ts tagSet) list() []string {
+	return append([]string{}, ts.tags...)
+}
+
+func (ts tagSet) empty() bool {
+	return len(ts.tags) == 0
+}
+
+// matches returns true if any tag in the set covers the tag
+func (ts tagSet) matches(tag string) bool {
+	if len(ts.exact) > 0 {
+		for t := tag; ; {
+			if _, ok := ts.exact[t]; ok {
+				return true
+			}
+			i := strings.LastIndexByte(t, '.')
+			if i < 0 {
+				break
+			}
+			t = t[:i]
+		}
+	}
+
+	for _, p := range ts.patterns {
+		if tagMatches(p, tag) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesAny returns true if any tag in the set covers any of the tags
+func (ts tagSet) matchesAny(tags []string) bool {
+	if ts.empty() {
+		return false
+	}
+	for _, t := range tags {
+		if ts.matches(t) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,217 @@
+package lg
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTagSet(t *testing.T) {
+	ts := newTagSet([]string{"red", "server.db", "red", "server.*.pool"})
+	require.Equal(t, []string{"red", "server.db", "server.*.pool"}, ts.list())
+	require.Equal(t, []string{"server.*.pool"}, ts.patterns)
+	require.True(t, ts.matches("red"))
+	require.True(t, ts.matches("red.one"))
+	require.True(t, ts.matches("server.db.conn"))
+	require.True(t, ts.matches("server.cache.pool"))
+	require.False(t, ts.matches("server"))
+	require.False(t, ts.matches("blue"))
+
+	more := ts.with([]string{"blue"})
+	require.True(t, more.matches("blue"))
+	require.False(t, ts.matches("blue")) // sets are immutable
+
+	less := more.without([]string{"red", "server.*.pool"})
+	require.Equal(t, []string{"server.db", "blue"}, less.list())
+	require.False(t, less.matches("server.cache.pool"))
+	require.True(t, ts.matches("red"))
+
+	require.True(t, newTagSet(nil).empty())
+	require.False(t, newTagSet(nil).matchesAny([]string{"red"}))
+}
+
+func TestConcurrentDebugChanges(t *testing.T) {
+	a := &ArrayAppender{}
+	logger := NewLogger()
+	logger.Configure(MinimalFormat, a.Log)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				logger.EnableDebugModeFor("red")
+				logger.ExcludeDebugModeFor("blue")
+				logger.DisableDebugModeFor("red")
+				logger.RemoveDebugModeExclusionFor("blue")
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				logger.TagDebugf([]string{"red"}, "one")
+				logger.IsDebugModeFor("blue")
+			}
+		}()
+	}
+	wg.Wait()
+
+	logger.EnableDebugModeFor("green")
+	logger.EnableDebugModeFor("yellow")
+	require.Equal(t, []string{"green", "yellow"}, logger.DebugModeTags())
+}
+
+func BenchmarkParallelDebugWithDebugOff(b *testing.B) {
+	b.ReportAllocs()
+	logger := NewLogger()
+	logger.Configure(MinimalFormat, NullAppender)
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			logger.Debugf("one %s", "formatted")
+		}
+	})
+}
+
+func BenchmarkParallelTagDebugWithDebugOff(b *testing.B) {
+	b.ReportAllocs()
+	logger := NewLogger()
+	logger.Configure(MinimalFormat, NullAppender)
+
+	tags := []string{"red", "blue"}
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			logger.TagDebugf(tags, "one %s", "formatted")
+		}
+	})
+}
+
+func BenchmarkParallelTagDebugWithOtherTagOn(b *testing.B) {
+	b.ReportAllocs()
+	logger := NewLogger()
+	logger.Configure(MinimalFormat, NullAppender)
+	logger.EnableDebugModeFor("green", "yellow", "server.db")
+
+	tags := []string{"red", "blue.one.two"}
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			logger.TagDebugf(tags, "one %s", "formatted")
+		}
+	})
+}
+
+func BenchmarkParallelTagDebugWithDebugOn(b *testing.B) {
+	b.ReportAllocs()
+	logger := NewLogger()
+	logger.Configure(MinimalFormat, NullAppender)
+	logger.EnableDebugModeFor("red")
+
+	tags := []string{"red"}
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			logger.TagDebugf(tags, "one %s", "formatted")
+		}
+	})
+}
+
+func TestSetDebugMode(t *testing.T) {
+	logger := NewLogger()
+	logger.EnableDebugModeFor("red")
+	logger.SetDebugMode(true, []string{"blue", "green"}, []string{"heartbeat"})
+
+	require.True(t, logger.IsDebugMode())
+	require.Equal(t, []string{"blue", "green"}, logger.DebugModeTags())
+	require.Equal(t, []string{"heartbeat"}, logger.DebugModeExclusions())
+	require.False(t, logger.IsDebugModeFor("heartbeat"))
+
+	logger.SetDebugMode(false, nil, nil)
+	require.False(t, logger.IsDebugMode())
+	require.False(t, logger.IsDebugModeFor("blue"))
+	require.Empty(t, logger.DebugModeTags())
+}
+
+func TestSuppressedDebugAppender(t *testing.T) {
+	a := &EntryArrayAppender{}
+	suppressed := &EntryArrayAppender{}
+	logger := NewLoggerWithEntryAppender(a.Append)
+	logger.EnableCallerCapture(0)
+
+	logger.Debugf("dropped")
+	logger.SetSuppressedDebugAppender(suppressed.Append)
+
+	logger.Printf("printed")
+	logger.Debugf("one %d", 1)
+	logger.WithTags("red").With("user", "bob").Debugf("two")
+	logger.TagDebugf([]string{"blue"}, "three")
+
+	logger.EnableDebugModeFor("red")
+	logger.ExcludeDebugModeFor("red.skip")
+	logger.TagDebugf([]string{"red"}, "printed debug")
+	logger.TagDebugf([]string{"red", "red.skip"}, "four")
+	logger.TagDebugf([]string{"green"}, "five")
+	logger.WithForceDebug().Debugf("forced")
+
+	require.Len(t, a.Entries, 3)
+	require.Equal(t, "printed", a.Entries[0].Message())
+	require.Equal(t, "printed debug", a.Entries[1].Message())
+	require.Equal(t, "forced", a.Entries[2].Message())
+
+	require.Len(t, suppressed.Entries, 5)
+	for i, msg := range []string{"one 1", "two", "three", "four", "five"} {
+		e := suppressed.Entries[i]
+		require.Equal(t, msg, e.Message())
+		require.True(t, e.Debug)
+		require.NotNil(t, e.Caller)
+		require.Equal(t, "github.com/sasbury/lg.TestSuppressedDebugAppender", e.Caller.Function)
+	}
+	require.Equal(t, []string{"red"}, suppressed.Entries[1].Tags)
+	require.Equal(t, Fields{String("user", "bob")}, suppressed.Entries[1].Fields)
+	require.Equal(t, []string{"red", "red.skip"}, suppressed.Entries[3].Tags)
+
+	logger.SetSuppressedDebugAppender(nil)
+	logger.Debugf("dropped")
+	require.Len(t, suppressed.Entries, 5)
+}
+
+func TestMissingDebugState(t *testing.T) {
+	a := &ArrayAppender{}
+	logger := &Logger{}
+	logger.shared.Store(&config{})
+	logger.Configure(MinimalFormat, a.Log)
+
+	require.False(t, logger.IsDebugMode())
+	require.False(t, logger.IsDebugModeFor("red"))
+	require.Empty(t, logger.DebugModeTags())
+	logger.Debugf("one")
+	logger.TagDebugf([]string{"red"}, "two")
+	require.Empty(t, a.Entries)
+
+	logger.EnableDebugModeFor("red")
+	logger.TagDebugf([]string{"red"}, "three")
+	require.Equal(t, []string{"three"}, a.Entries)
+}
+
+func TestConfigureWithDebugMode(t *testing.T) {
+	first := &ArrayAppender{}
+	logger := NewLoggerWithConfig(MinimalFormat, first.Log)
+	logger.EnableDebugModeFor("red")
+
+	second := &ArrayAppender{}
+	logger.ConfigureWithDebugMode(FullFormat, second.Log, false, []string{"blue"}, []string{"blue.skip"})
+	require.False(t, logger.IsDebugMode())
+	require.Equal(t, []string{"blue"}, logger.DebugModeTags())
+	require.Equal(t, []string{"blue.skip"}, logger.DebugModeExclusions())
+
+	logger.TagDebugf([]string{"red"}, "dropped")
+	logger.TagDebugf([]string{"blue"}, "printed")
+	logger.TagDebugf([]string{"blue.skip"}, "excluded")
+	require.Empty(t, first.Entries)
+	require.Len(t, second.Entries, 1)
+	require.Contains(t, second.Entries[0], "[DBG] [blue] printed")
+
+	formatter, appender := logger.Configuration()
+	require.NotNil(t, formatter)
+	require.NotNil(t, appender)
+}
@@ -0,0 +1,141 @@
+package lg
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Entry is a single log record, it is created by the logger once it decides to print and is passed to the configured EntryAppender.
+// Args are the arguments passed to the logging call and are not copied, appenders that keep an entry after returning
+// should keep the Message rather than the Format and Args.
+type Entry struct {
+	Time   time.Time
+	Debug  bool
+	Tags   []string
+	Format string
+	Args   []interface{}
+	Fields Fields
+	Caller *Caller
+	// Stack holds the frames of the calling goroutine, starting at the caller, for PrintStackf and TagPrintStackf
+	Stack []Caller
+	// Goroutines holds the stacks of every goroutine, as formatted by runtime.Stack, for PrintAllStacksf
+	Goroutines string
+}
+
+// Caller holds the source location of a logging call, or a frame in a stack
+type Caller struct {
+	File     string
+	Line     int
+	Function string
+}
+
+// String returns the file, with its directory, and line like "lg/entry.go:42"
+func (c *Caller) String() string {
+	dir, file := filepath.Split(c.File)
+	return filepath.Join(filepath.Base(dir), file) + ":" + strconv.Itoa(c.Line)
+}
+
+// callerAt returns the location of the function skip frames above the function calling callerAt
+func callerAt(skip int) *Caller {
+	var pcs [1]uintptr
+	if runtime.Callers(skip+2, pcs[:]) == 0 {
+		return nil
+	}
+
+	frame, _ := runtime.CallersFrames(pcs[:]).Next()
+	return &Caller{
+		File:     frame.File,
+		Line:     frame.Line,
+		Function: frame.Function,
+	}
+}
+
+// EntryAppender is used to write an entry to a destination without formatting it first, so that
+// appenders can route or filter on the tags, debug flag or fields.
+// The logger's lock will not be used protect the appender
+type EntryAppender func(entry *Entry) error
+
+// Message returns the entry's format rendered with its args, fields are not included
+func (e *Entry) Message() string {
+	return fmt.Sprintf(e.Format, e.Args...)
+}
+
+// HasTag returns true if the entry has the tag
+func (e *Entry) HasTag(tag string) bool {
+	for _, t := range e.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatWith converts the entry to a string with a LogFormatter. If the entry has fields, a caller or a stack,
+// a %v verb is added to the format and the entry is added to the args, as described for LogFormatter.
+func (e *Entry) FormatWith(formatter LogFormatter) string {
+	format, args := e.Format, e.Args
+	if len(e.Fields) > 0 || e.Caller != nil || len(e.Stack) > 0 || e.Goroutines != "" {
+		newArgs := make([]interface{}, len(args), len(args)+1)
+		copy(newArgs, args)
+		format, args = format+"%v", append(newArgs, entryArg{entry: e})
+	}
+	return formatter(e.Debug, e.Tags, e.Time, format, args...)
+}
+
+// entryArg is added to a formatter's args by FormatWith, it renders the entry's fields followed by any stack
+type entryArg struct {
+	entry *Entry
+}
+
+// Format implements fmt.Formatter
+func (a entryArg) Format(s fmt.State, verb rune) {
+	a.entry.Fields.Format(s, verb)
+	writeStack(s, a.entry)
+}
+
+// SplitEntry removes the entry that FormatWith adds to a formatter's format and args, so that a formatter can
+// render the entry's fields, caller and stack itself. If there is no entry, the format and args are returned unchanged
+// with a nil entry.
+func SplitEntry(format string, args []interface{}) (string, []interface{}, *Entry) {
+	if len(args) == 0 || len(format) < 2 || format[len(format)-2:] != "%v" {
+		return format, args, nil
+	}
+
+	arg, ok := args[len(args)-1].(entryArg)
+	if !ok {
+		return format, args, nil
+	}
+
+	return format[:len(format)-2], args[:len(args)-1], arg.entry
+}
+
+// NewFormattingAppender adapts a LogFormatter and LogAppender pair to an EntryAppender, each entry is formatted
+// and the result is passed to the appender. This is what Configure uses. Calls to the formatter are serialized
+// by a lock, so formatters don't need their own, the appender is called after the lock is released.
+func NewFormattingAppender(formatter LogFormatter, appender LogAppender) EntryAppender {
+	var lock sync.Mutex
+	return func(entry *Entry) error {
+		lock.Lock()
+		formatted := entry.FormatWith(formatter)
+		lock.Unlock()
+		return appender(formatted)
+	}
+}
+
+// EntryArrayAppender stores entries in an array for testing
+type EntryArrayAppender struct {
+	sync.Mutex
+	Entries []*Entry
+}
+
+// Append is EntryArrayAppender's implementation of EntryAppender
+func (a *EntryArrayAppender) Append(entry *Entry) error {
+	a.Lock()
+	a.Entries = append(a.Entries, entry)
+	a.Unlock()
+	return nil
+}
@@ -0,0 +1,103 @@
+package lg
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEntryAppender(t *testing.T) {
+	a := &EntryArrayAppender{}
+
+	logger := NewLoggerWithEntryAppender(a.Append)
+	logger.Debugf("one %s", "formatted")
+	logger.Printf("two %s", "formatted")
+	logger.With("user", "bob").TagPrintf([]string{"red"}, "three %s", "formatted")
+	logger.EnableDebugModeFor("blue")
+	logger.TagDebugf([]string{"blue"}, "four %s", "formatted")
+
+	require.Equal(t, 3, len(a.Entries))
+
+	require.False(t, a.Entries[0].Debug)
+	require.Equal(t, "two formatted", a.Entries[0].Message())
+	require.Nil(t, a.Entries[0].Tags)
+	require.Empty(t, a.Entries[0].Fields)
+	require.False(t, a.Entries[0].Time.IsZero())
+
+	require.Equal(t, "three %s", a.Entries[1].Format)
+	require.Equal(t, []interface{}{"formatted"}, a.Entries[1].Args)
+	require.True(t, a.Entries[1].HasTag("red"))
+	require.False(t, a.Entries[1].HasTag("blue"))
+	require.Equal(t, Fields{String("user", "bob")}, a.Entries[1].Fields)
+
+	require.True(t, a.Entries[2].Debug)
+	require.True(t, a.Entries[2].HasTag("blue"))
+}
+
+func TestFormattingAppender(t *testing.T) {
+	a := &ArrayAppender{}
+	appender := NewFormattingAppender(FullFormat, a.Log)
+
+	err := appender(&Entry{
+		Time:   time.Now(),
+		Debug:  true,
+		Tags:   []string{"red"},
+		Format: "one %s",
+		Args:   []interface{}{"formatted"},
+		Fields: Fields{Int("count", 2)},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(a.Entries))
+	require.True(t, strings.HasSuffix(a.Entries[0], "[DBG] [red] one formatted count=2"))
+}
+
+func TestFormattingAppenderSerializesFormatter(t *testing.T) {
+	a := &ArrayAppender{}
+
+	count := 0 // not protected, so the race detector fails the test unless formatter calls are serialized
+	counting := func(debug bool, tags []string, t time.Time, format string, args ...interface{}) string {
+		count++
+		return strconv.Itoa(count)
+	}
+	logger := NewLoggerWithConfig(counting, a.Log)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				logger.Printf("one")
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.Equal(t, 1000, count)
+	require.Len(t, a.Entries, 1000)
+}
+
+func TestConfigureEntryAppender(t *testing.T) {
+	a := &ArrayAppender{}
+	e := &EntryArrayAppender{}
+
+	logger := NewLogger()
+	logger.Configure(MinimalFormat, a.Log)
+	child := logger.WithTags("red")
+	child.Printf("one")
+
+	logger.ConfigureEntryAppender(e.Append)
+	child.Printf("two")
+
+	logger.ConfigureEntryAppender(nil)
+	require.NoError(t, child.Printf("three"))
+
+	require.Equal(t, []string{"one"}, a.Entries)
+	require.Equal(t, 1, len(e.Entries))
+	require.Equal(t, "two", e.Entries[0].Message())
+	require.Equal(t, []string{"red"}, e.Entries[0].Tags)
+}
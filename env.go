@@ -0,0 +1,194 @@
+package lg
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DefaultEnvPrefix is the prefix used by ApplyEnv, and by NewLoggerFromEnv with an empty prefix
+const DefaultEnvPrefix = "LG"
+
+// NewLoggerFromEnv creates a default logger and configures it from the environment variables with
+// the prefix, see ApplyEnvPrefix. An empty prefix uses DefaultEnvPrefix.
+func NewLoggerFromEnv(prefix string) (*Logger, error) {
+	if prefix == "" {
+		prefix = DefaultEnvPrefix
+	}
+
+	logger := NewLogger()
+	err := logger.ApplyEnvPrefix(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return logger, nil
+}
+
+// ApplyEnv configures the logger from the environment variables that start with DefaultEnvPrefix
+func (l *Logger) ApplyEnv() error {
+	return l.ApplyEnvPrefix(DefaultEnvPrefix)
+}
+
+/*
+ApplyEnvPrefix configures the logger from environment variables, using EnableDebugModeFor and Configure. With the prefix "LG"
+the variables are:
+
+	LG_DEBUG=server,db        turns on debug mode for a comma separated list of tags
+	LG_DEBUG_EXCLUDE=heartbeat excludes a comma separated list of tags from debug mode
+	LG_DEBUG_ALL=1            turns the debug flag on or off, using strconv.ParseBool
+	LG_FORMAT=full            sets the formatter to one of the names accepted by FormatterByName, console checks LG_OUTPUT
+	LG_OUTPUT=stderr          sets the appender to stderr, stdout or file:/path/to/file
+
+Variables that aren't set leave the logger unchanged, if only one of LG_FORMAT and LG_OUTPUT is set the other keeps its current value,
+or the default if the logger was configured with an EntryAppender. A file output is kept open until the logger's appender is replaced,
+by a later ApplyEnvPrefix or by Configure, use EnvOutput to reopen it with HandleSignals.
+All of the variables are validated before any are applied, including the wildcards in debug tags, if any are invalid an error
+is returned and the logger is not changed.
+*/
+func (l *Logger) ApplyEnvPrefix(prefix string) error {
+	env := func(name string) (string, bool) {
+		return os.LookupEnv(prefix + "_" + name)
+	}
+
+	var (
+		debugSet, debugAll bool
+		formatter          LogFormatter
+		outputName         string
+		err                error
+	)
+
+	if v, ok := env("DEBUG_ALL"); ok {
+		debugAll, err = strconv.ParseBool(strings.TrimSpace(v))
+		if err != nil {
+			return fmt.Errorf("invalid %s_DEBUG_ALL %q: %v", prefix, v, err)
+		}
+		debugSet = true
+	}
+
+	if v, ok := env("OUTPUT"); ok {
+		outputName = strings.TrimSpace(v)
+		if !isOutputName(outputName) {
+			return fmt.Errorf("invalid %s_OUTPUT %q: expected stderr, stdout or file:<path>", prefix, v)
+		}
+	}
+
+	if v, ok := env("FORMAT"); ok {
+		formatter, err = FormatterByNameFor(strings.TrimSpace(v), l.outputFile(outputName))
+		if err != nil {
+			return fmt.Errorf("invalid %s_FORMAT: %v", prefix, err)
+		}
+	}
+
+	debugValue, _ := env("DEBUG")
+	debugTags := splitTags(debugValue)
+	err = validateTags(debugTags)
+	if err != nil {
+		return fmt.Errorf("invalid %s_DEBUG: %v", prefix, err)
+	}
+
+	excludeValue, _ := env("DEBUG_EXCLUDE")
+	excludeTags := splitTags(excludeValue)
+	err = validateTags(excludeTags)
+	if err != nil {
+		return fmt.Errorf("invalid %s_DEBUG_EXCLUDE: %v", prefix, err)
+	}
+
+	if formatter != nil || outputName != "" {
+		currentFormatter, currentOutput := l.Configuration()
+
+		if formatter == nil {
+			formatter = currentFormatter
+		}
+		if formatter == nil {
+			formatter = SimpleFormat
+		}
+
+		output, file := currentOutput, l.EnvOutput()
+		if outputName != "" {
+			output, file, err = openOutput(outputName)
+			if err != nil {
+				return fmt.Errorf("invalid %s_OUTPUT: %v", prefix, err)
+			}
+		}
+		if output == nil {
+			output = StdErrAppender
+		}
+
+		l.setAppender(newAppender(formatter, output), formatter, output, file, nil)
+	}
+
+	if debugSet {
+		if debugAll {
+			l.EnableDebugMode()
+		} else {
+			l.DisableDebugMode()
+		}
+	}
+
+	if len(debugTags) > 0 {
+		l.EnableDebugModeFor(debugTags...)
+	}
+
+	if len(excludeTags) > 0 {
+		l.ExcludeDebugModeFor(excludeTags...)
+	}
+
+	return nil
+}
+
+// splitTags splits a comma separated list, ignoring empty entries
+func splitTags(list string) []string {
+	var tags []string
+	for _, t := range strings.Split(list, ",") {
+		t = strings.TrimSpace(t)
+		if t != "" {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// isOutputName returns true if the name is one openOutput accepts
+func isOutputName(name string) bool {
+	switch {
+	case name == "stderr", name == "stdout":
+		return true
+	case strings.HasPrefix(name, "file:"):
+		return len(name) > len("file:")
+	}
+	return false
+}
+
+// outputFile returns the file the output name writes to, for the console formatter, or nil for a file output.
+// An empty name is the logger's current output, which is checked as stderr unless it is a file opened by ApplyEnvPrefix.
+func (l *Logger) outputFile(name string) *os.File {
+	switch {
+	case name == "stdout":
+		return os.Stdout
+	case name == "stderr":
+		return os.Stderr
+	case name == "" && l.EnvOutput() == nil:
+		return os.Stderr
+	}
+	return nil
+}
+
+// openOutput returns the appender for an output name, files are opened with NewFileAppender and returned
+// so that they can be closed
+func openOutput(name string) (LogAppender, *FileAppender, error) {
+	switch {
+	case name == "stderr":
+		return StdErrAppender, nil, nil
+	case name == "stdout":
+		return StdOutAppender, nil, nil
+	case strings.HasPrefix(name, "file:"):
+		file, err := NewFileAppender(strings.TrimPrefix(name, "file:"))
+		if err != nil {
+			return nil, nil, err
+		}
+		return file.Log, file, nil
+	}
+	return nil, nil, fmt.Errorf("unknown output %q", name)
+}
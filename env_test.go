@@ -0,0 +1,160 @@
+package lg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLoggerFromEnv(t *testing.T) {
+	t.Setenv("LGTEST_DEBUG", "server, db,,")
+	t.Setenv("LGTEST_DEBUG_EXCLUDE", "heartbeat")
+	t.Setenv("LGTEST_DEBUG_ALL", "true")
+	t.Setenv("LGTEST_FORMAT", "Full")
+	t.Setenv("LGTEST_OUTPUT", "stdout")
+
+	logger, err := NewLoggerFromEnv("LGTEST")
+	require.NoError(t, err)
+	require.True(t, logger.IsDebugMode())
+	require.Equal(t, []string{"server", "db"}, logger.DebugModeTags())
+	require.Equal(t, []string{"heartbeat"}, logger.DebugModeExclusions())
+
+	formatter, appender := logger.Configuration()
+	require.NotNil(t, formatter)
+	require.NotNil(t, appender)
+}
+
+func TestNewLoggerFromEnvDefaultPrefix(t *testing.T) {
+	t.Setenv("LG_DEBUG", "red")
+
+	logger, err := NewLoggerFromEnv("")
+	require.NoError(t, err)
+	require.False(t, logger.IsDebugMode())
+	require.True(t, logger.IsDebugModeFor("red"))
+}
+
+func TestApplyEnvFormatKeepsAppender(t *testing.T) {
+	a := &ArrayAppender{}
+	logger := NewLogger()
+	logger.Configure(FullFormat, a.Log)
+
+	t.Setenv("LG_FORMAT", "minimal")
+	require.NoError(t, logger.ApplyEnv())
+
+	logger.TagPrintf([]string{"red"}, "one")
+	require.Equal(t, []string{"one"}, a.Entries)
+}
+
+func TestApplyEnvJSONFormat(t *testing.T) {
+	a := &ArrayAppender{}
+	logger := NewLogger()
+	logger.Configure(FullFormat, a.Log)
+
+	t.Setenv("LG_FORMAT", "json")
+	require.NoError(t, logger.ApplyEnv())
+
+	logger.With("user", "bob").TagPrintf([]string{"red"}, "one")
+	require.Len(t, a.Entries, 1)
+	require.True(t, strings.HasSuffix(a.Entries[0], `"level":"info","tags":["red"],"msg":"one","fields":{"user":"bob"}}`), a.Entries[0])
+}
+
+func TestApplyEnvFileOutput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lgenv")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "out.log")
+
+	logger := NewLogger()
+	t.Setenv("LG_OUTPUT", "file:"+path)
+	t.Setenv("LG_FORMAT", "minimal")
+	require.NoError(t, logger.ApplyEnv())
+
+	logger.Printf("one %s", "formatted")
+	logger.Printf("two %s", "formatted")
+
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "one formatted\ntwo formatted\n", string(content))
+
+	// the file stays open when only the format changes
+	file := logger.EnvOutput()
+	require.NotNil(t, file)
+	require.Equal(t, path, file.Path())
+	require.NoError(t, file.Reopen())
+	os.Unsetenv("LG_OUTPUT")
+	t.Setenv("LG_FORMAT", "simple")
+	require.NoError(t, logger.ApplyEnv())
+	require.Equal(t, file, logger.EnvOutput())
+	require.NoError(t, file.Log("three"))
+
+	// and is closed when the output is replaced
+	t.Setenv("LG_OUTPUT", "stdout")
+	require.NoError(t, logger.ApplyEnv())
+	require.Nil(t, logger.EnvOutput())
+	require.Error(t, file.Log("closed"))
+
+	t.Setenv("LG_OUTPUT", "file:"+path)
+	require.NoError(t, logger.ApplyEnv())
+	file = logger.EnvOutput()
+	require.NotNil(t, file)
+	logger.Configure(MinimalFormat, NullAppender)
+	require.Nil(t, logger.EnvOutput())
+	require.Error(t, file.Log("closed"))
+}
+
+func TestApplyEnvErrors(t *testing.T) {
+	a := &ArrayAppender{}
+	logger := NewLogger()
+	logger.Configure(MinimalFormat, a.Log)
+
+	t.Setenv("LG_DEBUG", "red")
+	t.Setenv("LG_DEBUG_ALL", "maybe")
+	err := logger.ApplyEnv()
+	require.Error(t, err)
+	require.True(t, strings.Contains(err.Error(), "LG_DEBUG_ALL"))
+	require.False(t, logger.IsDebugModeFor("red")) // nothing is applied
+
+	t.Setenv("LG_DEBUG_ALL", "0")
+	t.Setenv("LG_FORMAT", "fancy")
+	err = logger.ApplyEnv()
+	require.Error(t, err)
+	require.True(t, strings.Contains(err.Error(), "fancy"))
+
+	t.Setenv("LG_FORMAT", "simple")
+	t.Setenv("LG_OUTPUT", "file:")
+	err = logger.ApplyEnv()
+	require.Error(t, err)
+	require.True(t, strings.Contains(err.Error(), "LG_OUTPUT"))
+
+	t.Setenv("LG_OUTPUT", "file:/does/not/exist/out.log")
+	_, err = NewLoggerFromEnv("LG")
+	require.Error(t, err)
+
+	logger.Printf("one")
+	require.Equal(t, []string{"one"}, a.Entries)
+}
+
+func TestApplyEnvBadTagPattern(t *testing.T) {
+	logger := NewLoggerWithConfig(MinimalFormat, NullAppender)
+
+	t.Setenv("LG_DEBUG_ALL", "1")
+	t.Setenv("LG_DEBUG", "server,server.[db")
+	err := logger.ApplyEnv()
+	require.Error(t, err)
+	require.True(t, strings.Contains(err.Error(), "LG_DEBUG"))
+	require.True(t, strings.Contains(err.Error(), "server.[db"))
+	require.False(t, logger.IsDebugMode()) // nothing is applied
+	require.Empty(t, logger.DebugModeTags())
+
+	t.Setenv("LG_DEBUG", "server")
+	t.Setenv("LG_DEBUG_EXCLUDE", "heartbeat.[")
+	err = logger.ApplyEnv()
+	require.Error(t, err)
+	require.True(t, strings.Contains(err.Error(), "LG_DEBUG_EXCLUDE"))
+	require.Empty(t, logger.DebugModeTags())
+	require.Empty(t, logger.DebugModeExclusions())
+}
@@ -0,0 +1,260 @@
+package extras
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sasbury/lg"
+)
+
+// OverflowPolicy decides what an AsyncAppender does with an entry when its queue is full
+type OverflowPolicy int
+
+const (
+	// OverflowBlock waits for room in the queue
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNewest drops the entry being logged
+	OverflowDropNewest
+	// OverflowDropOldest drops the oldest entry in the queue to make room
+	OverflowDropOldest
+	// OverflowDropDebug drops the oldest debug entry in the queue to make room for an info entry,
+	// debug entries, and info entries when the queue has no debug entries, are dropped like OverflowDropNewest
+	OverflowDropDebug
+)
+
+// DefaultAsyncQueueSize is the queue size used when AsyncOptions.QueueSize isn't set
+const DefaultAsyncQueueSize = 1024
+
+// AsyncOptions configures an AsyncAppender, empty settings use the defaults
+type AsyncOptions struct {
+	QueueSize int // defaults to DefaultAsyncQueueSize
+	Overflow  OverflowPolicy
+	// OnError is called, on the writer goroutine, with errors from the wrapped appender
+	OnError func(error)
+	// Formatter formats entries passed to Append, defaults to lg.SimpleFormat
+	Formatter lg.LogFormatter
+}
+
+// AsyncStats holds an AsyncAppender's counters
+type AsyncStats struct {
+	Queued       int    // entries waiting to be written
+	Written      uint64 // entries passed to the wrapped appender
+	Errors       uint64 // entries the wrapped appender returned an error for
+	Dropped      uint64 // info entries dropped because the queue was full
+	DroppedDebug uint64 // debug entries dropped because the queue was full
+}
+
+// asyncItem is a formatted entry waiting in the queue
+type asyncItem struct {
+	entry string
+	debug bool
+}
+
+/*
+AsyncAppender passes entries to another appender on a background goroutine, so that a slow appender doesn't
+stall the code that is logging.
+
+Entries wait in a queue that holds QueueSize entries, not counting the batch being written, and the overflow
+policy decides what happens when it is full. Entries passed to Append are formatted before they are queued, so
+the entry's args can change after Append returns, and the debug flag is kept for OverflowDropDebug. Entries passed
+to Log are treated as info entries.
+
+Errors from the wrapped appender can't be returned to the code that logged the entry, they are passed to OnError
+instead. Flush waits for the queue to be written and Close writes what is left before stopping the goroutine.
+*/
+type AsyncAppender struct {
+	sync.Mutex
+	appender lg.LogAppender
+	opts     AsyncOptions
+
+	queue    []asyncItem
+	spare    []asyncItem
+	writing  bool
+	closed   bool
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	waiters  []chan struct{}
+	done     chan struct{}
+
+	stats AsyncStats
+}
+
+// NewAsyncAppender starts the goroutine that writes to the appender
+func NewAsyncAppender(appender lg.LogAppender, opts AsyncOptions) *AsyncAppender {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = DefaultAsyncQueueSize
+	}
+	if opts.Formatter == nil {
+		opts.Formatter = lg.SimpleFormat
+	}
+
+	aa := &AsyncAppender{
+		appender: appender,
+		opts:     opts,
+		queue:    make([]asyncItem, 0, opts.QueueSize),
+		spare:    make([]asyncItem, 0, opts.QueueSize),
+		done:     make(chan struct{}),
+	}
+	aa.notEmpty = sync.NewCond(&aa.Mutex)
+	aa.notFull = sync.NewCond(&aa.Mutex)
+
+	go aa.run()
+
+	return aa
+}
+
+// Log is the async appender's implementation of LogAppender, the entry is queued as an info entry
+func (aa *AsyncAppender) Log(entry string) error {
+	return aa.enqueue(asyncItem{entry: entry})
+}
+
+// Append is the async appender's implementation of EntryAppender, the entry is formatted and queued
+func (aa *AsyncAppender) Append(entry *lg.Entry) error {
+	return aa.enqueue(asyncItem{entry: entry.FormatWith(aa.opts.Formatter), debug: entry.Debug})
+}
+
+// enqueue adds the item to the queue, applying the overflow policy if it is full. Dropped entries
+// don't return an error.
+func (aa *AsyncAppender) enqueue(item asyncItem) error {
+	aa.Lock()
+	defer aa.Unlock()
+
+	for !aa.closed && len(aa.queue) >= aa.opts.QueueSize {
+		switch aa.opts.Overflow {
+		case OverflowDropNewest:
+			aa.drop(item)
+			return nil
+		case OverflowDropOldest:
+			aa.drop(aa.queue[0])
+			aa.queue = append(aa.queue[:0], aa.queue[1:]...)
+		case OverflowDropDebug:
+			index := -1
+			if !item.debug {
+				for i, queued := range aa.queue {
+					if queued.debug {
+						index = i
+						break
+					}
+				}
+			}
+			if index < 0 {
+				aa.drop(item)
+				return nil
+			}
+			aa.drop(aa.queue[index])
+			aa.queue = append(aa.queue[:index], aa.queue[index+1:]...)
+		default:
+			aa.notFull.Wait()
+		}
+	}
+
+	if aa.closed {
+		return ErrAppenderClosed
+	}
+
+	aa.queue = append(aa.queue, item)
+	aa.notEmpty.Signal()
+	return nil
+}
+
+// drop counts a dropped item, the lock is held
+func (aa *AsyncAppender) drop(item asyncItem) {
+	if item.debug {
+		aa.stats.DroppedDebug++
+	} else {
+		aa.stats.Dropped++
+	}
+}
+
+// run writes batches of entries until the appender is closed and the queue is empty
+func (aa *AsyncAppender) run() {
+	defer close(aa.done)
+
+	aa.Lock()
+	for {
+		for len(aa.queue) == 0 && !aa.closed {
+			aa.notEmpty.Wait()
+		}
+		if len(aa.queue) == 0 {
+			aa.release()
+			aa.Unlock()
+			return
+		}
+
+		batch := aa.queue
+		aa.queue = aa.spare[:0]
+		aa.writing = true
+		aa.notFull.Broadcast()
+		aa.Unlock()
+
+		var written, errors uint64
+		for _, item := range batch {
+			err := aa.appender(item.entry)
+			written++
+			if err != nil {
+				errors++
+				if aa.opts.OnError != nil {
+					aa.opts.OnError(err)
+				}
+			}
+		}
+
+		aa.Lock()
+		aa.spare = batch[:0]
+		aa.writing = false
+		aa.stats.Written += written
+		aa.stats.Errors += errors
+		if len(aa.queue) == 0 {
+			aa.release()
+		}
+	}
+}
+
+// release wakes up any calls to Flush, the lock is held
+func (aa *AsyncAppender) release() {
+	for _, w := range aa.waiters {
+		close(w)
+	}
+	aa.waiters = nil
+}
+
+// Flush waits until the queue is empty and the last batch has been written, or the context is done
+func (aa *AsyncAppender) Flush(ctx context.Context) error {
+	aa.Lock()
+	if len(aa.queue) == 0 && !aa.writing {
+		aa.Unlock()
+		return nil
+	}
+	w := make(chan struct{})
+	aa.waiters = append(aa.waiters, w)
+	aa.Unlock()
+
+	select {
+	case <-w:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close writes the entries in the queue and stops the writer goroutine, entries logged after Close, or blocked
+// waiting for room when it is called, return ErrAppenderClosed. The wrapped appender isn't closed.
+func (aa *AsyncAppender) Close() error {
+	aa.Lock()
+	aa.closed = true
+	aa.notEmpty.Broadcast()
+	aa.notFull.Broadcast()
+	aa.Unlock()
+
+	<-aa.done
+	return nil
+}
+
+// Stats returns the appender's counters
+func (aa *AsyncAppender) Stats() AsyncStats {
+	aa.Lock()
+	defer aa.Unlock()
+	stats := aa.stats
+	stats.Queued = len(aa.queue)
+	return stats
+}
@@ -0,0 +1,214 @@
+package extras
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sasbury/lg"
+	"github.com/stretchr/testify/require"
+)
+
+// gatedAppender blocks each write until the gate is opened
+type gatedAppender struct {
+	lg.ArrayAppender
+	gate    chan struct{}
+	started chan struct{}
+}
+
+func newGatedAppender() *gatedAppender {
+	return &gatedAppender{
+		gate:    make(chan struct{}),
+		started: make(chan struct{}, 100),
+	}
+}
+
+func (ga *gatedAppender) Log(entry string) error {
+	ga.started <- struct{}{}
+	<-ga.gate
+	return ga.ArrayAppender.Log(entry)
+}
+
+// fill blocks the writer on a first entry and then queues the entries
+func fill(t *testing.T, aa *AsyncAppender, ga *gatedAppender, entries ...*lg.Entry) {
+	require.NoError(t, aa.Log("first"))
+	<-ga.started
+	for _, e := range entries {
+		require.NoError(t, aa.Append(e))
+	}
+}
+
+func infoEntry(msg string) *lg.Entry {
+	return &lg.Entry{Format: msg}
+}
+
+func debugEntry(msg string) *lg.Entry {
+	return &lg.Entry{Format: msg, Debug: true}
+}
+
+func TestAsyncAppender(t *testing.T) {
+	array := &lg.ArrayAppender{}
+	aa := NewAsyncAppender(array.Log, AsyncOptions{Formatter: lg.MinimalFormat})
+
+	logger := lg.NewLoggerWithEntryAppender(aa.Append)
+	args := []interface{}{"one"}
+	logger.Printf("%s", args...)
+	args[0] = "changed"
+	for i := 0; i < 100; i++ {
+		logger.Printf("%d", i)
+	}
+
+	require.NoError(t, aa.Flush(context.Background()))
+	array.Lock()
+	require.Len(t, array.Entries, 101)
+	require.Equal(t, "one", array.Entries[0])
+	require.Equal(t, "99", array.Entries[100])
+	array.Unlock()
+
+	require.Equal(t, AsyncStats{Written: 101}, aa.Stats())
+
+	require.NoError(t, aa.Close())
+	require.Equal(t, ErrAppenderClosed, aa.Log("closed"))
+	require.NoError(t, aa.Close())
+	require.NoError(t, aa.Flush(context.Background()))
+}
+
+func TestAsyncDropNewest(t *testing.T) {
+	ga := newGatedAppender()
+	aa := NewAsyncAppender(ga.Log, AsyncOptions{QueueSize: 2, Overflow: OverflowDropNewest, Formatter: lg.MinimalFormat})
+
+	fill(t, aa, ga, infoEntry("a"), debugEntry("b"), infoEntry("c"), debugEntry("d"))
+	require.Equal(t, AsyncStats{Queued: 2, Dropped: 1, DroppedDebug: 1}, aa.Stats())
+
+	close(ga.gate)
+	require.NoError(t, aa.Close())
+	require.Equal(t, []string{"first", "a", "b"}, ga.Entries)
+}
+
+func TestAsyncDropOldest(t *testing.T) {
+	ga := newGatedAppender()
+	aa := NewAsyncAppender(ga.Log, AsyncOptions{QueueSize: 2, Overflow: OverflowDropOldest, Formatter: lg.MinimalFormat})
+
+	fill(t, aa, ga, infoEntry("a"), debugEntry("b"), infoEntry("c"), debugEntry("d"))
+	require.Equal(t, AsyncStats{Queued: 2, Dropped: 1, DroppedDebug: 1}, aa.Stats())
+
+	close(ga.gate)
+	require.NoError(t, aa.Close())
+	require.Equal(t, []string{"first", "c", "d"}, ga.Entries)
+}
+
+func TestAsyncDropDebug(t *testing.T) {
+	ga := newGatedAppender()
+	aa := NewAsyncAppender(ga.Log, AsyncOptions{QueueSize: 3, Overflow: OverflowDropDebug, Formatter: lg.MinimalFormat})
+
+	fill(t, aa, ga, infoEntry("a"), debugEntry("b"), debugEntry("c"), infoEntry("d"), debugEntry("e"), infoEntry("f"), infoEntry("g"))
+	require.Equal(t, AsyncStats{Queued: 3, Dropped: 1, DroppedDebug: 3}, aa.Stats())
+
+	close(ga.gate)
+	require.NoError(t, aa.Close())
+	require.Equal(t, []string{"first", "a", "d", "f"}, ga.Entries)
+}
+
+func TestAsyncBlock(t *testing.T) {
+	ga := newGatedAppender()
+	aa := NewAsyncAppender(ga.Log, AsyncOptions{QueueSize: 1, Formatter: lg.MinimalFormat})
+
+	fill(t, aa, ga, infoEntry("a"))
+
+	logged := make(chan error)
+	go func() {
+		logged <- aa.Log("b")
+	}()
+
+	select {
+	case <-logged:
+		t.Fatal("log didn't block")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	ga.gate <- struct{}{}
+	require.NoError(t, <-logged)
+
+	close(ga.gate)
+	require.NoError(t, aa.Flush(context.Background()))
+	require.Equal(t, []string{"first", "a", "b"}, ga.Entries)
+	require.NoError(t, aa.Close())
+}
+
+func TestAsyncBlockedClose(t *testing.T) {
+	ga := newGatedAppender()
+	aa := NewAsyncAppender(ga.Log, AsyncOptions{QueueSize: 1, Formatter: lg.MinimalFormat})
+
+	fill(t, aa, ga, infoEntry("a"))
+
+	logged := make(chan error)
+	go func() {
+		logged <- aa.Log("b")
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	closed := make(chan error)
+	go func() {
+		closed <- aa.Close()
+	}()
+
+	require.Equal(t, ErrAppenderClosed, <-logged)
+	close(ga.gate)
+	require.NoError(t, <-closed)
+	require.Equal(t, []string{"first", "a"}, ga.Entries)
+}
+
+func TestAsyncFlushTimeout(t *testing.T) {
+	ga := newGatedAppender()
+	aa := NewAsyncAppender(ga.Log, AsyncOptions{})
+
+	fill(t, aa, ga)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	require.Equal(t, context.DeadlineExceeded, aa.Flush(ctx))
+
+	close(ga.gate)
+	require.NoError(t, aa.Flush(context.Background()))
+	require.NoError(t, aa.Close())
+}
+
+func TestAsyncErrors(t *testing.T) {
+	var lock sync.Mutex
+	var errs []error
+
+	count := 0
+	aa := NewAsyncAppender(func(entry string) error {
+		count++
+		if count%2 == 0 {
+			return fmt.Errorf("failed %s", entry)
+		}
+		return nil
+	}, AsyncOptions{OnError: func(err error) {
+		lock.Lock()
+		errs = append(errs, err)
+		lock.Unlock()
+	}})
+
+	for i := 0; i < 4; i++ {
+		aa.Log(fmt.Sprint(i))
+	}
+	require.NoError(t, aa.Close())
+
+	require.Equal(t, []error{errors.New("failed 1"), errors.New("failed 3")}, errs)
+	require.Equal(t, AsyncStats{Written: 4, Errors: 2}, aa.Stats())
+}
+
+func BenchmarkAsyncAppender(b *testing.B) {
+	aa := NewAsyncAppender(lg.NullAppender, AsyncOptions{Overflow: OverflowDropNewest})
+	defer aa.Close()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			aa.Log("entry")
+		}
+	})
+}
@@ -0,0 +1,413 @@
+package extras
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sasbury/lg"
+)
+
+/*
+Config describes a logger, it can be loaded from a JSON file like:
+
+	{
+		"debug": false,
+		"debugTags": ["server.db"],
+		"debugExclude": ["heartbeat"],
+		"format": "full",
+		"appender": {
+			"type": "branching",
+			"children": [
+				{"type": "stderr"},
+				{"type": "rolling", "prefix": "/var/log/server", "suffix": "log", "maxFileSize": 1048576, "maxFiles": 5}
+			]
+		}
+	}
+
+The format is one of the names accepted by lg.FormatterByName and defaults to "simple", "console" checks stdout or
+stderr if the appender tree only writes to one of them and uses no color otherwise. The appender defaults to stderr.
+*/
+type Config struct {
+	Debug        bool            `json:"debug"`
+	DebugTags    []string        `json:"debugTags"`
+	DebugExclude []string        `json:"debugExclude"`
+	Format       string          `json:"format"`
+	Appender     *AppenderConfig `json:"appender"`
+}
+
+/*
+AppenderConfig describes an appender, the type determines which of the other settings are used:
+
+	stderr, stdout, null - no settings
+	file                 - path, see lg.NewFileAppender
+	rolling              - prefix, suffix, maxFileSize and maxFiles, see NewRollingFileAppender
+	branching            - children, see NewBranchingAppender
+*/
+type AppenderConfig struct {
+	Type        string            `json:"type"`
+	Path        string            `json:"path,omitempty"`
+	Prefix      string            `json:"prefix,omitempty"`
+	Suffix      string            `json:"suffix,omitempty"`
+	MaxFileSize int64             `json:"maxFileSize,omitempty"`
+	MaxFiles    int16             `json:"maxFiles,omitempty"`
+	Children    []*AppenderConfig `json:"children,omitempty"`
+}
+
+// LoadConfig reads and validates a JSON config file
+func LoadConfig(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseConfig(data)
+}
+
+// ParseConfig parses and validates a JSON config, unknown settings are an error
+func ParseConfig(data []byte) (*Config, error) {
+	config := &Config{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid logging config: %v", err)
+	}
+
+	err = config.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// Validate checks the debug tags, the formatter name and the appender tree, without opening any files
+func (c *Config) Validate() error {
+	err := lg.ValidateDebugTags(c.DebugTags...)
+	if err != nil {
+		return fmt.Errorf("invalid logging config: debugTags: %v", err)
+	}
+
+	err = lg.ValidateDebugTags(c.DebugExclude...)
+	if err != nil {
+		return fmt.Errorf("invalid logging config: debugExclude: %v", err)
+	}
+
+	if c.Format != "" {
+		_, err = lg.FormatterByName(c.Format)
+		if err != nil {
+			return fmt.Errorf("invalid logging config: %v", err)
+		}
+	}
+
+	if c.Appender != nil {
+		return c.Appender.Validate()
+	}
+
+	return nil
+}
+
+// Validate checks the appender type and its required settings, including any children
+func (ac *AppenderConfig) Validate() error {
+	switch ac.Type {
+	case "stderr", "stdout", "null":
+	case "file":
+		if ac.Path == "" {
+			return fmt.Errorf("invalid logging config: file appender requires a path")
+		}
+	case "rolling":
+		if ac.Prefix == "" || ac.Suffix == "" {
+			return fmt.Errorf("invalid logging config: rolling appender requires a prefix and suffix")
+		}
+	case "branching":
+		for _, child := range ac.Children {
+			if child == nil {
+				return fmt.Errorf("invalid logging config: branching appender has an empty child")
+			}
+			err := child.Validate()
+			if err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("invalid logging config: unknown appender type %q", ac.Type)
+	}
+	return nil
+}
+
+// Apply configures the logger, replacing its debug state, formatter and appender in a single update. The appender
+// tree is opened before the logger is changed, if that fails the logger is left as it was.
+// The returned appender should be closed when the logger no longer uses it.
+func (c *Config) Apply(logger *lg.Logger) (*ConfiguredAppender, error) {
+	appenderConfig := c.Appender
+	if appenderConfig == nil {
+		appenderConfig = &AppenderConfig{Type: "stderr"}
+	}
+
+	formatter := lg.SimpleFormat
+	if c.Format != "" {
+		var err error
+		formatter, err = lg.FormatterByNameFor(c.Format, appenderConfig.consoleFile())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	appender, err := appenderConfig.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	logger.ConfigureWithDebugMode(formatter, appender.Log, c.Debug, c.DebugTags, c.DebugExclude)
+
+	return appender, nil
+}
+
+// consoleFile returns the file the console formatter checks for a terminal, stderr or stdout if the tree only
+// writes to one of them and nil if it writes to files
+func (ac *AppenderConfig) consoleFile() *os.File {
+	switch ac.Type {
+	case "stderr":
+		return os.Stderr
+	case "stdout":
+		return os.Stdout
+	case "branching":
+		var f *os.File
+		for i, child := range ac.Children {
+			childFile := child.consoleFile()
+			if i > 0 && childFile != f {
+				return nil
+			}
+			f = childFile
+		}
+		return f
+	}
+	return nil
+}
+
+// Open creates the appender tree, opening any files it needs
+func (ac *AppenderConfig) Open() (*ConfiguredAppender, error) {
+	err := ac.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	configured := &ConfiguredAppender{}
+	configured.appender, err = ac.build(&configured.closers)
+	if err != nil {
+		configured.Close()
+		return nil, err
+	}
+
+	return configured, nil
+}
+
+// build creates the appender, adding anything that needs to be closed to closers
+func (ac *AppenderConfig) build(closers *[]io.Closer) (lg.LogAppender, error) {
+	switch ac.Type {
+	case "stderr":
+		return lg.StdErrAppender, nil
+	case "stdout":
+		return lg.StdOutAppender, nil
+	case "null":
+		return lg.NullAppender, nil
+	case "file":
+		file, err := lg.NewFileAppender(ac.Path)
+		if err != nil {
+			return nil, err
+		}
+		*closers = append(*closers, file)
+		return file.Log, nil
+	case "rolling":
+		rolling := NewRollingFileAppender(ac.Prefix, ac.Suffix, ac.MaxFileSize, ac.MaxFiles)
+		*closers = append(*closers, rolling)
+		return rolling.Log, nil
+	case "branching":
+		children := make([]lg.LogAppender, 0, len(ac.Children))
+		for _, c := range ac.Children {
+			child, err := c.build(closers)
+			if err != nil {
+				return nil, err
+			}
+			children = append(children, child)
+		}
+		return NewBranchingAppender(children...).Log, nil
+	}
+	return nil, fmt.Errorf("invalid logging config: unknown appender type %q", ac.Type)
+}
+
+// ErrAppenderClosed is returned when an entry is logged to a closed ConfiguredAppender that has no replacement
+var ErrAppenderClosed = errors.New("appender is closed")
+
+// ConfiguredAppender is an appender tree created from an AppenderConfig. When it is replaced, it waits for
+// entries that are being logged to finish before closing its files, and passes any entries that arrive
+// afterwards to its replacement, so that no entries are dropped while a logger is reconfigured.
+type ConfiguredAppender struct {
+	sync.RWMutex
+	appender    lg.LogAppender
+	closers     []io.Closer
+	closed      bool
+	replacement *ConfiguredAppender
+}
+
+// Log is the configured appender's implementation of LogAppender
+func (ca *ConfiguredAppender) Log(entry string) error {
+	ca.RLock()
+	if ca.closed {
+		next := ca.replacement
+		ca.RUnlock()
+		if next == nil {
+			return ErrAppenderClosed
+		}
+		return next.Log(entry)
+	}
+	defer ca.RUnlock()
+	return ca.appender(entry)
+}
+
+// Close closes any files in the tree, entries logged after Close return ErrAppenderClosed
+func (ca *ConfiguredAppender) Close() error {
+	return ca.Replace(nil)
+}
+
+// Replace closes any files in the tree, entries logged afterwards are passed to the replacement.
+// Calling Replace, or Close, on an appender that is already closed does nothing.
+func (ca *ConfiguredAppender) Replace(replacement *ConfiguredAppender) error {
+	ca.Lock()
+	defer ca.Unlock()
+
+	if ca.closed {
+		return nil
+	}
+	ca.closed = true
+	ca.replacement = replacement
+
+	var errors []error
+	for _, c := range ca.closers {
+		err := c.Close()
+		if err != nil {
+			errors = append(errors, err)
+		}
+	}
+	ca.closers = nil
+
+	if len(errors) > 0 {
+		return BranchingError{
+			Children: errors,
+		}
+	}
+
+	return nil
+}
+
+// ConfigWatcher polls a config file and applies it to a logger whenever it changes
+type ConfigWatcher struct {
+	sync.Mutex
+	logger   *lg.Logger
+	path     string
+	onError  func(error)
+	last     []byte
+	current  *ConfiguredAppender
+	stop     chan struct{}
+	stopped  chan struct{}
+	stopOnce sync.Once
+}
+
+// WatchConfig loads the config file, applies it to the logger and then checks the file for changes every interval.
+// An error is returned if the first load fails. Errors during later loads are passed to onError, which can be nil,
+// and leave the logger unchanged. When a new config is applied the previous appender tree is closed.
+func WatchConfig(logger *lg.Logger, path string, interval time.Duration, onError func(error)) (*ConfigWatcher, error) {
+	watcher := &ConfigWatcher{
+		logger:  logger,
+		path:    path,
+		onError: onError,
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	_, err := watcher.Reload()
+	if err != nil {
+		return nil, err
+	}
+
+	go watcher.poll(interval)
+
+	return watcher, nil
+}
+
+func (w *ConfigWatcher) poll(interval time.Duration) {
+	defer close(w.stopped)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			_, err := w.Reload()
+			if err != nil && w.onError != nil {
+				w.onError(err)
+			}
+		}
+	}
+}
+
+// Reload reads the config file and applies it if it has changed since the last time it was applied,
+// returns true if the config was applied
+func (w *ConfigWatcher) Reload() (bool, error) {
+	w.Lock()
+	defer w.Unlock()
+
+	data, err := ioutil.ReadFile(w.path)
+	if err != nil {
+		return false, err
+	}
+
+	if w.current != nil && bytes.Equal(data, w.last) {
+		return false, nil
+	}
+
+	config, err := ParseConfig(data)
+	if err != nil {
+		return false, err
+	}
+
+	next, err := config.Apply(w.logger)
+	if err != nil {
+		return false, err
+	}
+
+	previous := w.current
+	w.current = next
+	w.last = data
+
+	if previous != nil {
+		err = previous.Replace(next)
+	}
+
+	return true, err
+}
+
+// Appender returns the appender tree created from the current config
+func (w *ConfigWatcher) Appender() *ConfiguredAppender {
+	w.Lock()
+	defer w.Unlock()
+	return w.current
+}
+
+// Stop stops polling the file, the logger keeps the current config
+func (w *ConfigWatcher) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+	})
+	<-w.stopped
+}
@@ -0,0 +1,203 @@
+package extras
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sasbury/lg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfig(t *testing.T) {
+	config, err := ParseConfig([]byte(`{
+		"debug": true,
+		"debugTags": ["server.db"],
+		"debugExclude": ["heartbeat"],
+		"format": "full",
+		"appender": {
+			"type": "branching",
+			"children": [
+				{"type": "stderr"},
+				{"type": "rolling", "prefix": "/tmp/server", "suffix": "log", "maxFileSize": 2048, "maxFiles": 3}
+			]
+		}
+	}`))
+	require.NoError(t, err)
+	require.True(t, config.Debug)
+	require.Equal(t, []string{"server.db"}, config.DebugTags)
+	require.Equal(t, []string{"heartbeat"}, config.DebugExclude)
+	require.Equal(t, "full", config.Format)
+	require.Equal(t, "branching", config.Appender.Type)
+	require.Len(t, config.Appender.Children, 2)
+	require.Equal(t, int64(2048), config.Appender.Children[1].MaxFileSize)
+	require.Equal(t, int16(3), config.Appender.Children[1].MaxFiles)
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	bad := []string{
+		`{"debug": "yes"}`,
+		`{"verbose": true}`,
+		`{"format": "fancy"}`,
+		`{"debugTags": ["server", "server.[db"]}`,
+		`{"debugExclude": ["heartbeat.["]}`,
+		`{"appender": {"type": "carrier-pigeon"}}`,
+		`{"appender": {"type": "file"}}`,
+		`{"appender": {"type": "rolling", "prefix": "/tmp/x"}}`,
+		`{"appender": {"type": "branching", "children": [{"type": "stdout"}, null]}}`,
+		`{"appender": {"type": "branching", "children": [{"type": "nope"}]}}`,
+	}
+
+	for _, b := range bad {
+		_, err := ParseConfig([]byte(b))
+		require.Error(t, err, b)
+	}
+
+	_, err := ParseConfig([]byte(`{"debugTags": ["server.[db"]}`))
+	require.True(t, strings.Contains(err.Error(), "server.[db"))
+
+	_, err = LoadConfig("/does/not/exist.json")
+	require.Error(t, err)
+}
+
+func TestConfigApply(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lgconfig")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "out.log")
+
+	config, err := ParseConfig([]byte(fmt.Sprintf(`{
+		"debugTags": ["red"],
+		"format": "minimal",
+		"appender": {"type": "branching", "children": [{"type": "null"}, {"type": "file", "path": %q}]}
+	}`, path)))
+	require.NoError(t, err)
+
+	logger := lg.NewLogger()
+	appender, err := config.Apply(logger)
+	require.NoError(t, err)
+
+	logger.TagDebugf([]string{"red"}, "one")
+	logger.TagDebugf([]string{"blue"}, "two")
+	require.NoError(t, appender.Close())
+	require.Equal(t, ErrAppenderClosed, logger.Printf("three"))
+
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "one\n", string(content))
+
+	// a failed apply leaves the logger alone
+	a := &lg.ArrayAppender{}
+	logger.Configure(lg.MinimalFormat, a.Log)
+	config.Appender.Children[1].Path = filepath.Join(dir, "missing", "out.log")
+	config.DebugTags = []string{"blue"}
+	_, err = config.Apply(logger)
+	require.Error(t, err)
+	logger.TagDebugf([]string{"red"}, "four")
+	require.Equal(t, []string{"four"}, a.Entries)
+}
+
+func TestConfiguredAppenderReplace(t *testing.T) {
+	first, err := (&AppenderConfig{Type: "null"}).Open()
+	require.NoError(t, err)
+
+	a := &lg.ArrayAppender{}
+	second := &ConfiguredAppender{appender: a.Log}
+
+	require.NoError(t, first.Log("one"))
+	require.NoError(t, first.Replace(second))
+	require.NoError(t, first.Log("two")) // forwarded
+	require.NoError(t, first.Close())    // already closed, keeps forwarding
+	require.NoError(t, first.Log("three"))
+	require.Equal(t, []string{"two", "three"}, a.Entries)
+}
+
+func writeConfig(t *testing.T, path string, content string) {
+	tmp := path + ".tmp"
+	require.NoError(t, ioutil.WriteFile(tmp, []byte(content), 0644))
+	require.NoError(t, os.Rename(tmp, path))
+}
+
+func TestWatchConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lgwatch")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	configPath := filepath.Join(dir, "lg.json")
+	logPrefix := filepath.Join(dir, "server")
+	writeConfig(t, configPath, fmt.Sprintf(`{"format": "minimal", "appender": {"type": "rolling", "prefix": %q, "suffix": "log", "maxFiles": 1}}`, logPrefix))
+
+	var errLock sync.Mutex
+	var errs []error
+	logger := lg.NewLogger()
+	watcher, err := WatchConfig(logger, configPath, 10*time.Millisecond, func(err error) {
+		errLock.Lock()
+		errs = append(errs, err)
+		errLock.Unlock()
+	})
+	require.NoError(t, err)
+	defer watcher.Stop()
+
+	first := watcher.Appender()
+	logger.TagDebugf([]string{"red"}, "one")
+	logger.Printf("two")
+
+	writeConfig(t, configPath, fmt.Sprintf(`{"format": "minimal", "debugTags": ["red"], "appender": {"type": "rolling", "prefix": %q, "suffix": "log", "maxFiles": 1}}`, logPrefix))
+	require.Eventually(t, func() bool { return logger.IsDebugModeFor("red") }, time.Second, 5*time.Millisecond)
+	require.NotEqual(t, first, watcher.Appender())
+	logger.TagDebugf([]string{"red"}, "three")
+
+	writeConfig(t, configPath, `{"format": "???"}`)
+	require.Eventually(t, func() bool {
+		errLock.Lock()
+		defer errLock.Unlock()
+		return len(errs) > 0
+	}, time.Second, 5*time.Millisecond)
+	require.True(t, logger.IsDebugModeFor("red")) // bad configs are ignored
+
+	// an unchanged file isn't applied again
+	applied, err := watcher.Reload()
+	require.Error(t, err)
+	require.False(t, applied)
+	watcher.Stop()
+
+	require.NoError(t, watcher.Appender().Close())
+	content, err := ioutil.ReadFile(logPrefix + ".log")
+	require.NoError(t, err)
+	require.Equal(t, "two\nthree\n", string(content))
+}
+
+func TestWatchConfigErrors(t *testing.T) {
+	_, err := WatchConfig(lg.NewLogger(), "/does/not/exist.json", time.Second, nil)
+	require.Error(t, err)
+
+	dir, err := ioutil.TempDir("", "lgwatch")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	configPath := filepath.Join(dir, "lg.json")
+	writeConfig(t, configPath, `{"appender": {"type": "file", "path": "/does/not/exist/out.log"}}`)
+	_, err = WatchConfig(lg.NewLogger(), configPath, time.Second, nil)
+	require.Error(t, err)
+	require.True(t, strings.Contains(err.Error(), "no such file"))
+}
+
+func TestConfigConsoleFile(t *testing.T) {
+	require.Equal(t, os.Stderr, (&AppenderConfig{Type: "stderr"}).consoleFile())
+	require.Equal(t, os.Stdout, (&AppenderConfig{Type: "stdout"}).consoleFile())
+	require.Nil(t, (&AppenderConfig{Type: "file", Path: "out.log"}).consoleFile())
+	require.Equal(t, os.Stdout, (&AppenderConfig{Type: "branching", Children: []*AppenderConfig{
+		{Type: "stdout"}, {Type: "branching", Children: []*AppenderConfig{{Type: "stdout"}}},
+	}}).consoleFile())
+	require.Nil(t, (&AppenderConfig{Type: "branching", Children: []*AppenderConfig{
+		{Type: "stdout"}, {Type: "stderr"},
+	}}).consoleFile())
+	require.Nil(t, (&AppenderConfig{Type: "branching", Children: []*AppenderConfig{
+		{Type: "stdout"}, {Type: "rolling"},
+	}}).consoleFile())
+}
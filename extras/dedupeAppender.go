@@ -0,0 +1,185 @@
+package extras
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sasbury/lg"
+)
+
+// DefaultDedupeWindow is the window used when DedupeOptions.Window isn't set, the same as syslogd
+const DefaultDedupeWindow = 30 * time.Second
+
+// DedupeOptions configures a DedupeAppender, empty settings use the defaults
+type DedupeOptions struct {
+	// Window is how long repeats are held before their summary is written, defaults to DefaultDedupeWindow
+	Window time.Duration
+	// OnError is called with errors from the next appender when a summary is written by the timer
+	OnError func(error)
+	// Formatter formats entries passed to Append and the summaries, defaults to lg.SimpleFormat
+	Formatter lg.LogFormatter
+	// Clock returns the time used for summaries, defaults to time.Now
+	Clock func() time.Time
+}
+
+/*
+DedupeAppender is middleware that collapses runs of identical entries, like syslogd. The first entry in a run is
+passed to the next appender and the repeats are counted, then when a different entry arrives, the window ends or
+the appender is closed a summary like
+
+	last message repeated 1234 times
+
+is written. Entries passed to Append are compared by their debug flag, tags, message and fields, so the time
+doesn't matter, and summaries have the repeated entry's tags. Entries passed to Log are compared as strings, which
+means the formatter used shouldn't include the time.
+
+The lock is held while the next appender is called, so concurrent entries and summaries are written in order.
+*/
+type DedupeAppender struct {
+	sync.Mutex
+	next lg.LogAppender
+	opts DedupeOptions
+
+	started bool
+	last    string
+	debug   bool
+	tags    []string
+	repeats int
+	timer   *time.Timer
+	run     int
+	closed  bool
+}
+
+// NewDedupeAppender returns a new dedupe appender with the provided next appender
+func NewDedupeAppender(next lg.LogAppender, opts DedupeOptions) *DedupeAppender {
+	if opts.Window <= 0 {
+		opts.Window = DefaultDedupeWindow
+	}
+	if opts.Formatter == nil {
+		opts.Formatter = lg.SimpleFormat
+	}
+	if opts.Clock == nil {
+		opts.Clock = time.Now
+	}
+
+	return &DedupeAppender{
+		next: next,
+		opts: opts,
+	}
+}
+
+// Append is the dedupe appender's implementation of EntryAppender
+func (da *DedupeAppender) Append(entry *lg.Entry) error {
+	key := strconv.FormatBool(entry.Debug) + "\x00" + strings.Join(entry.Tags, "\x00") + "\x00" + entry.FormatWith(lg.MinimalFormat)
+
+	return da.dedupe(key, entry.Debug, entry.Tags, func() string {
+		return entry.FormatWith(da.opts.Formatter)
+	})
+}
+
+// Log is the dedupe appender's implementation of LogAppender
+func (da *DedupeAppender) Log(entry string) error {
+	return da.dedupe(entry, false, nil, func() string {
+		return entry
+	})
+}
+
+// dedupe counts the entry if it repeats the last one, otherwise the last one's summary is written followed by the
+// entry, format is only called for entries that are written
+func (da *DedupeAppender) dedupe(key string, debug bool, tags []string, format func() string) error {
+	da.Lock()
+	defer da.Unlock()
+
+	if da.closed {
+		return ErrAppenderClosed
+	}
+
+	if da.started && key == da.last {
+		da.repeats++
+		if da.timer == nil {
+			run := da.run
+			da.timer = time.AfterFunc(da.opts.Window, func() {
+				da.expire(run)
+			})
+		}
+		return nil
+	}
+
+	var errors []error
+	if err := da.flush(); err != nil {
+		errors = append(errors, err)
+	}
+
+	da.started = true
+	da.last = key
+	da.debug = debug
+	da.tags = append([]string{}, tags...)
+	da.repeats = 0
+
+	if err := da.next(format()); err != nil {
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return BranchingError{
+			Children: errors,
+		}
+	}
+
+	return nil
+}
+
+// expire writes the summary for the run when its window ends, runs that have already ended are ignored
+func (da *DedupeAppender) expire(run int) {
+	da.Lock()
+	defer da.Unlock()
+
+	if da.closed || run != da.run {
+		return
+	}
+
+	if err := da.flush(); err != nil && da.opts.OnError != nil {
+		da.opts.OnError(err)
+	}
+}
+
+// flush writes the summary for any held repeats and starts a new run, later repeats of the same entry are
+// counted again. The lock is held.
+func (da *DedupeAppender) flush() error {
+	if da.timer != nil {
+		da.timer.Stop()
+		da.timer = nil
+	}
+	da.run++
+
+	if da.repeats == 0 {
+		return nil
+	}
+
+	entry := &lg.Entry{
+		Time:   da.opts.Clock(),
+		Debug:  da.debug,
+		Tags:   da.tags,
+		Format: "last message repeated %d times",
+		Args:   []interface{}{da.repeats},
+	}
+	da.repeats = 0
+
+	return da.next(entry.FormatWith(da.opts.Formatter))
+}
+
+// Close writes the summary for any held repeats, entries logged after Close return ErrAppenderClosed. The next
+// appender isn't closed.
+func (da *DedupeAppender) Close() error {
+	da.Lock()
+	defer da.Unlock()
+
+	if da.closed {
+		return nil
+	}
+	da.closed = true
+
+	return da.flush()
+}
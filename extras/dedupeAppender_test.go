@@ -0,0 +1,127 @@
+package extras
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sasbury/lg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDedupeAppender(t *testing.T) {
+	array := &lg.ArrayAppender{}
+	da := NewDedupeAppender(array.Log, DedupeOptions{Window: time.Hour, Formatter: lg.MinimalFormat})
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, da.Log("retrying"))
+	}
+	require.Equal(t, []string{"retrying"}, array.Entries)
+
+	require.NoError(t, da.Log("connected"))
+	require.NoError(t, da.Log("connected"))
+	require.NoError(t, da.Log("retrying"))
+	require.Equal(t, []string{"retrying", "last message repeated 4 times", "connected", "last message repeated 1 times", "retrying"}, array.Entries)
+
+	// nothing is held, so close doesn't write a summary
+	require.NoError(t, da.Close())
+	require.Len(t, array.Entries, 5)
+	require.Equal(t, ErrAppenderClosed, da.Log("closed"))
+	require.NoError(t, da.Close())
+}
+
+func TestDedupeEntries(t *testing.T) {
+	array := &lg.ArrayAppender{}
+	da := NewDedupeAppender(array.Log, DedupeOptions{Window: time.Hour, Formatter: lg.FullFormat})
+
+	logger := lg.NewLoggerWithEntryAppender(da.Append)
+	logger.SetDebugMode(true, nil, nil)
+	for i := 0; i < 3; i++ {
+		logger.With(lg.Int("attempt", 1)).TagPrintf([]string{"db"}, "retrying %s", "query")
+	}
+	logger.With(lg.Int("attempt", 2)).TagPrintf([]string{"db"}, "retrying %s", "query")
+	logger.With(lg.Int("attempt", 2)).TagDebugf([]string{"db"}, "retrying %s", "query")
+	logger.With(lg.Int("attempt", 2)).TagDebugf([]string{"db"}, "retrying %s", "query")
+	logger.With(lg.Int("attempt", 2)).TagDebugf([]string{"server"}, "retrying %s", "query")
+
+	require.NoError(t, da.Close())
+	require.Len(t, array.Entries, 6)
+	require.Contains(t, array.Entries[0], "[INF] [db] retrying query attempt=1")
+	require.Contains(t, array.Entries[1], "[INF] [db] last message repeated 2 times")
+	require.Contains(t, array.Entries[2], "[INF] [db] retrying query attempt=2")
+	require.Contains(t, array.Entries[3], "[DBG] [db] retrying query attempt=2")
+	require.Contains(t, array.Entries[4], "[DBG] [db] last message repeated 1 times")
+	require.Contains(t, array.Entries[5], "[DBG] [server] retrying query attempt=2")
+}
+
+func TestDedupeWindow(t *testing.T) {
+	var lock sync.Mutex
+	var errs []error
+
+	array := &lg.ArrayAppender{}
+	da := NewDedupeAppender(array.Log, DedupeOptions{Window: 10 * time.Millisecond, Formatter: lg.MinimalFormat})
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, da.Log("retrying"))
+	}
+
+	require.Eventually(t, func() bool {
+		da.Lock()
+		defer da.Unlock()
+		return len(array.Entries) == 2
+	}, 5*time.Second, 5*time.Millisecond)
+
+	// repeats after the summary start a new count
+	require.NoError(t, da.Log("retrying"))
+	require.NoError(t, da.Close())
+	require.Equal(t, []string{"retrying", "last message repeated 2 times", "last message repeated 1 times"}, array.Entries)
+
+	bad := NewDedupeAppender(func(entry string) error {
+		if entry == "last message repeated 1 times" {
+			return fmt.Errorf("failed")
+		}
+		return nil
+	}, DedupeOptions{Window: 10 * time.Millisecond, Formatter: lg.MinimalFormat, OnError: func(err error) {
+		lock.Lock()
+		errs = append(errs, err)
+		lock.Unlock()
+	}})
+	defer bad.Close()
+
+	require.NoError(t, bad.Log("retrying"))
+	require.NoError(t, bad.Log("retrying"))
+	require.Eventually(t, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(errs) == 1
+	}, 5*time.Second, 5*time.Millisecond)
+}
+
+func TestDedupeConcurrent(t *testing.T) {
+	array := &lg.ArrayAppender{}
+	da := NewDedupeAppender(array.Log, DedupeOptions{Window: time.Hour, Formatter: lg.MinimalFormat})
+
+	var wg sync.WaitGroup
+	for g := 0; g < 10; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				da.Log("same")
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.NoError(t, da.Close())
+	require.Equal(t, []string{"same", "last message repeated 999 times"}, array.Entries)
+}
+
+func TestDedupeNextError(t *testing.T) {
+	da := NewDedupeAppender(BadAppender, DedupeOptions{Window: time.Hour})
+	require.Error(t, da.Log("first"))
+	require.NoError(t, da.Log("first"))
+	require.Error(t, da.Log("second"))
+	require.NoError(t, da.Close())
+}
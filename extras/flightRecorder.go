@@ -0,0 +1,149 @@
+package extras
+
+import (
+	"sync"
+
+	"github.com/sasbury/lg"
+)
+
+// DefaultFlightRecorderEntries is the number of entries kept when neither limit in FlightRecorderOptions is set
+const DefaultFlightRecorderEntries = 1000
+
+// FlightRecorderOptions configures a FlightRecorder, if both limits are set the entries are kept within both
+type FlightRecorderOptions struct {
+	MaxEntries int // the number of entries kept, 0 means no limit
+	MaxBytes   int // the total size of the entries kept, 0 means no limit
+	// Formatter formats entries passed to Append, defaults to lg.FullFormat
+	Formatter lg.LogFormatter
+}
+
+/*
+FlightRecorder is an appender that keeps the most recent entries in memory, so that debug output can always be
+captured and only written out when something goes wrong. Pass Append to Logger.SetSuppressedDebugAppender to record
+the debug entries the logger doesn't print, and Log to a BranchingAppender to record the printed ones as well:
+
+	recorder := extras.NewFlightRecorder(extras.FlightRecorderOptions{MaxEntries: 5000})
+	logger.SetSuppressedDebugAppender(recorder.Append)
+	logger.Configure(lg.FullFormat, extras.NewBranchingAppender(lg.StdErrAppender, recorder.Log).Log)
+
+Entries passed to Append are formatted when they are recorded, so that their size is known and their args can
+change afterwards. When a limit is reached the oldest entries are dropped, the newest entry is always kept even if
+it is larger than MaxBytes.
+
+Dump writes the entries to another appender, and Snapshot returns a copy of them, neither clears the recorder.
+*/
+type FlightRecorder struct {
+	sync.Mutex
+	opts    FlightRecorderOptions
+	entries []string
+	head    int
+	bytes   int
+}
+
+// NewFlightRecorder returns an empty flight recorder
+func NewFlightRecorder(opts FlightRecorderOptions) *FlightRecorder {
+	if opts.MaxEntries <= 0 && opts.MaxBytes <= 0 {
+		opts.MaxEntries = DefaultFlightRecorderEntries
+	}
+	if opts.Formatter == nil {
+		opts.Formatter = lg.FullFormat
+	}
+
+	return &FlightRecorder{
+		opts: opts,
+	}
+}
+
+// Append is the flight recorder's implementation of EntryAppender
+func (fr *FlightRecorder) Append(entry *lg.Entry) error {
+	return fr.Log(entry.FormatWith(fr.opts.Formatter))
+}
+
+// Log is the flight recorder's implementation of LogAppender
+func (fr *FlightRecorder) Log(entry string) error {
+	fr.Lock()
+	defer fr.Unlock()
+
+	fr.entries = append(fr.entries, entry)
+	fr.bytes += len(entry)
+
+	for fr.len() > 1 && fr.full() {
+		fr.bytes -= len(fr.entries[fr.head])
+		fr.entries[fr.head] = ""
+		fr.head++
+	}
+
+	// move the entries down once half of the slice is dropped ones, so it doesn't keep growing
+	if fr.head > len(fr.entries)/2 {
+		n := copy(fr.entries, fr.entries[fr.head:])
+		for i := n; i < len(fr.entries); i++ {
+			fr.entries[i] = ""
+		}
+		fr.entries = fr.entries[:n]
+		fr.head = 0
+	}
+
+	return nil
+}
+
+// len returns the number of entries kept, the lock is held
+func (fr *FlightRecorder) len() int {
+	return len(fr.entries) - fr.head
+}
+
+// full returns true if the entries are over either limit, the lock is held
+func (fr *FlightRecorder) full() bool {
+	return (fr.opts.MaxEntries > 0 && fr.len() > fr.opts.MaxEntries) ||
+		(fr.opts.MaxBytes > 0 && fr.bytes > fr.opts.MaxBytes)
+}
+
+// Snapshot returns a copy of the entries, oldest first
+func (fr *FlightRecorder) Snapshot() []string {
+	fr.Lock()
+	defer fr.Unlock()
+	return append([]string{}, fr.entries[fr.head:]...)
+}
+
+// Dump writes the entries, oldest first, to the appender. Every entry is written even if some fail, and the errors
+// are returned in a BranchingError. The lock isn't held while the appender is called, so the recorder can be used
+// by the logger the appender belongs to.
+func (fr *FlightRecorder) Dump(appender lg.LogAppender) error {
+	var errors []error
+
+	for _, entry := range fr.Snapshot() {
+		err := appender(entry)
+		if err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	if len(errors) > 0 {
+		return BranchingError{
+			Children: errors,
+		}
+	}
+
+	return nil
+}
+
+// DumpOnPanic dumps the entries to the appender if the goroutine is panicking, and then continues the panic.
+// It has to be deferred directly:
+//
+//	defer recorder.DumpOnPanic(lg.StdErrAppender)
+func (fr *FlightRecorder) DumpOnPanic(appender lg.LogAppender) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	fr.Dump(appender)
+	panic(r)
+}
+
+// Clear removes all of the entries
+func (fr *FlightRecorder) Clear() {
+	fr.Lock()
+	fr.entries = nil
+	fr.head = 0
+	fr.bytes = 0
+	fr.Unlock()
+}
@@ -0,0 +1,129 @@
+package extras
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sasbury/lg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlightRecorderEntries(t *testing.T) {
+	fr := NewFlightRecorder(FlightRecorderOptions{MaxEntries: 3})
+	require.Empty(t, fr.Snapshot())
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, fr.Log(fmt.Sprint(i)))
+	}
+	require.Equal(t, []string{"7", "8", "9"}, fr.Snapshot())
+	require.LessOrEqual(t, len(fr.entries), 6)
+
+	array := &lg.ArrayAppender{}
+	require.NoError(t, fr.Dump(array.Log))
+	require.Equal(t, []string{"7", "8", "9"}, array.Entries)
+	require.Len(t, fr.Snapshot(), 3)
+
+	fr.Clear()
+	require.Empty(t, fr.Snapshot())
+	require.NoError(t, fr.Log("after"))
+	require.Equal(t, []string{"after"}, fr.Snapshot())
+
+	require.Equal(t, DefaultFlightRecorderEntries, NewFlightRecorder(FlightRecorderOptions{}).opts.MaxEntries)
+}
+
+func TestFlightRecorderBytes(t *testing.T) {
+	fr := NewFlightRecorder(FlightRecorderOptions{MaxBytes: 10})
+
+	fr.Log("aaaa")
+	fr.Log("bbbb")
+	require.Equal(t, []string{"aaaa", "bbbb"}, fr.Snapshot())
+	fr.Log("cccc")
+	require.Equal(t, []string{"bbbb", "cccc"}, fr.Snapshot())
+
+	// the newest entry is kept even if it is over the limit
+	fr.Log(strings.Repeat("d", 20))
+	require.Equal(t, []string{strings.Repeat("d", 20)}, fr.Snapshot())
+	fr.Log("e")
+	require.Equal(t, []string{"e"}, fr.Snapshot())
+	require.Equal(t, 1, fr.bytes)
+
+	both := NewFlightRecorder(FlightRecorderOptions{MaxEntries: 2, MaxBytes: 100})
+	both.Log("a")
+	both.Log("b")
+	both.Log("c")
+	require.Equal(t, []string{"b", "c"}, both.Snapshot())
+}
+
+func TestFlightRecorderSuppressedDebug(t *testing.T) {
+	fr := NewFlightRecorder(FlightRecorderOptions{Formatter: lg.FullFormat})
+	primary := &lg.ArrayAppender{}
+
+	logger := lg.NewLogger()
+	logger.Configure(lg.MinimalFormat, NewBranchingAppender(primary.Log, fr.Log).Log)
+	logger.SetSuppressedDebugAppender(fr.Append)
+
+	args := []interface{}{"pool"}
+	logger.TagDebugf([]string{"db"}, "checking %s", args...)
+	args[0] = "changed"
+	logger.Printf("query failed")
+
+	require.Equal(t, []string{"query failed"}, primary.Entries)
+
+	snapshot := fr.Snapshot()
+	require.Len(t, snapshot, 2)
+	require.True(t, strings.HasSuffix(snapshot[0], "[DBG] [db] checking pool"), snapshot[0])
+	require.Equal(t, "query failed", snapshot[1])
+}
+
+func TestFlightRecorderDumpOnPanic(t *testing.T) {
+	fr := NewFlightRecorder(FlightRecorderOptions{})
+	fr.Log("before")
+	array := &lg.ArrayAppender{}
+
+	func() {
+		defer func() {
+			require.Equal(t, "boom", recover())
+		}()
+		defer fr.DumpOnPanic(array.Log)
+		panic("boom")
+	}()
+	require.Equal(t, []string{"before"}, array.Entries)
+
+	func() {
+		defer fr.DumpOnPanic(array.Log)
+	}()
+	require.Len(t, array.Entries, 1)
+}
+
+func TestFlightRecorderDumpErrors(t *testing.T) {
+	fr := NewFlightRecorder(FlightRecorderOptions{})
+	fr.Log("one")
+	fr.Log("two")
+
+	err := fr.Dump(BadAppender)
+	require.Error(t, err)
+	require.Len(t, err.(BranchingError).Children, 2)
+}
+
+func TestFlightRecorderConcurrent(t *testing.T) {
+	fr := NewFlightRecorder(FlightRecorderOptions{MaxEntries: 50})
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				fr.Log("entry")
+				if i%100 == 0 {
+					fr.Snapshot()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.Len(t, fr.Snapshot(), 50)
+}
@@ -0,0 +1,218 @@
+package extras
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sasbury/lg"
+)
+
+// DefaultJournaldSocket is the socket journald reads native protocol messages from
+const DefaultJournaldSocket = "/run/systemd/journal/socket"
+
+// ErrJournaldUnsupported is returned by NewJournaldAppender on platforms other than linux
+var ErrJournaldUnsupported = errors.New("journald is only supported on linux")
+
+// JournaldOptions configures a JournaldAppender, empty settings use the defaults
+type JournaldOptions struct {
+	Socket        string // defaults to DefaultJournaldSocket
+	Identifier    string // the SYSLOG_IDENTIFIER, defaults to the base name of the program
+	InfoPriority  int    // defaults to SyslogInformational
+	DebugPriority int    // defaults to SyslogDebug
+}
+
+/*
+JournaldAppender sends entries to the systemd journal using its native protocol, so that fields can be searched
+with journalctl, for example journalctl LG_TAGS=server or journalctl USER_ID=42.
+
+Each entry is sent with these journal fields:
+
+	MESSAGE            the message, and any stack
+	PRIORITY           the info or debug priority
+	SYSLOG_IDENTIFIER  the identifier
+	LG_TAGS            the tags, separated by commas, left out if there are none
+	CODE_FILE          the caller's file, line and function if caller capture is on
+	CODE_LINE
+	CODE_FUNC
+
+Fields are added with their keys in upper case, characters other than letters, digits and underscores are
+replaced with underscores and leading underscores are removed. Keys that start with a digit, or are one of the
+fields above, are prefixed with "LG_".
+
+Entries too large for a datagram are written to a sealed memfd, and the file descriptor is sent to journald instead.
+If memfd_create isn't available an unlinked file in /dev/shm, /tmp or /var/tmp is used, the directories journald
+accepts unsealed files from.
+
+Each entry is addressed to the socket path, like systemd's own client, so the appender keeps working when
+journald restarts. Use Append as an EntryAppender, Log can be used as a LogAppender, in which case entries
+only have a message, priority and identifier.
+*/
+type JournaldAppender struct {
+	sync.Mutex
+	opts   JournaldOptions
+	addr   *net.UnixAddr
+	conn   *net.UnixConn
+	closed bool
+}
+
+// NewJournaldAppender creates the socket used to send entries, an error is returned if the journal socket
+// doesn't exist or, on platforms other than linux, ErrJournaldUnsupported
+func NewJournaldAppender(opts JournaldOptions) (*JournaldAppender, error) {
+	if !journaldSupported {
+		return nil, ErrJournaldUnsupported
+	}
+
+	if opts.Socket == "" {
+		opts.Socket = DefaultJournaldSocket
+	}
+	if opts.Identifier == "" {
+		opts.Identifier = filepath.Base(os.Args[0])
+	}
+	if opts.InfoPriority == 0 {
+		opts.InfoPriority = SyslogInformational
+	}
+	if opts.DebugPriority == 0 {
+		opts.DebugPriority = SyslogDebug
+	}
+
+	_, err := os.Stat(opts.Socket)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := openJournal()
+	if err != nil {
+		return nil, err
+	}
+
+	return &JournaldAppender{
+		opts: opts,
+		addr: &net.UnixAddr{Name: opts.Socket, Net: "unixgram"},
+		conn: conn,
+	}, nil
+}
+
+// Append is the journald appender's implementation of EntryAppender
+func (ja *JournaldAppender) Append(entry *lg.Entry) error {
+	return ja.send(ja.encode(entry))
+}
+
+// Log is the journald appender's implementation of LogAppender, entries are sent with the info priority
+func (ja *JournaldAppender) Log(entry string) error {
+	return ja.send(ja.encode(&lg.Entry{
+		Time:   time.Now(),
+		Format: "%s",
+		Args:   []interface{}{entry},
+	}))
+}
+
+// Close closes the socket, entries logged after Close return ErrAppenderClosed
+func (ja *JournaldAppender) Close() error {
+	ja.Lock()
+	defer ja.Unlock()
+	if ja.closed {
+		return nil
+	}
+	ja.closed = true
+	return ja.conn.Close()
+}
+
+func (ja *JournaldAppender) send(data []byte) error {
+	ja.Lock()
+	defer ja.Unlock()
+
+	if ja.closed {
+		return ErrAppenderClosed
+	}
+
+	return writeJournal(ja.conn, ja.addr, data)
+}
+
+// reservedJournalFields are the fields set by the appender, entry fields with these names are prefixed
+var reservedJournalFields = map[string]bool{
+	"MESSAGE":           true,
+	"PRIORITY":          true,
+	"SYSLOG_IDENTIFIER": true,
+	"LG_TAGS":           true,
+	"CODE_FILE":         true,
+	"CODE_LINE":         true,
+	"CODE_FUNC":         true,
+}
+
+// encode converts the entry to the native protocol
+func (ja *JournaldAppender) encode(entry *lg.Entry) []byte {
+	buf := &bytes.Buffer{}
+
+	writeJournalField(buf, "MESSAGE", messageWithStack(entry))
+
+	priority := ja.opts.InfoPriority
+	if entry.Debug {
+		priority = ja.opts.DebugPriority
+	}
+	writeJournalField(buf, "PRIORITY", strconv.Itoa(priority))
+	writeJournalField(buf, "SYSLOG_IDENTIFIER", ja.opts.Identifier)
+
+	if len(entry.Tags) > 0 {
+		writeJournalField(buf, "LG_TAGS", strings.Join(entry.Tags, ","))
+	}
+
+	if entry.Caller != nil {
+		writeJournalField(buf, "CODE_FILE", entry.Caller.File)
+		writeJournalField(buf, "CODE_LINE", strconv.Itoa(entry.Caller.Line))
+		writeJournalField(buf, "CODE_FUNC", entry.Caller.Function)
+	}
+
+	for _, f := range entry.Fields {
+		writeJournalField(buf, journalFieldName(f.Key), fmt.Sprint(f.Value))
+	}
+
+	return buf.Bytes()
+}
+
+// journalFieldName converts a field key to a valid journal field name, see JournaldAppender
+func journalFieldName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		}
+		return '_'
+	}, key)
+	name = strings.TrimLeft(name, "_")
+
+	if name == "" || (name[0] >= '0' && name[0] <= '9') || reservedJournalFields[name] {
+		name = "LG_" + name
+	}
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+// writeJournalField writes NAME=value and a new line, values with new lines are written as the name, a new line,
+// the length as a 64 bit little endian integer, the value and a new line
+func writeJournalField(buf *bytes.Buffer, name string, value string) {
+	buf.WriteString(name)
+	if !strings.ContainsRune(value, '\n') {
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+
+	buf.WriteByte('\n')
+	binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
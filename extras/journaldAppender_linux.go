@@ -0,0 +1,151 @@
+//go:build linux
+
+package extras
+
+import (
+	"errors"
+	"net"
+	"os"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+// journaldSupported is true on platforms where the native protocol is implemented
+const journaldSupported = true
+
+// memfdCreateCalls are the memfd_create system call numbers, the syscall package doesn't define it for every architecture
+var memfdCreateCalls = map[string]uintptr{
+	"386":      356,
+	"amd64":    319,
+	"arm":      385,
+	"arm64":    279,
+	"loong64":  279,
+	"mips":     4354,
+	"mipsle":   4354,
+	"mips64":   5314,
+	"mips64le": 5314,
+	"ppc64":    360,
+	"ppc64le":  360,
+	"riscv64":  279,
+	"s390x":    350,
+}
+
+// The memfd_create flags and file seals from the kernel headers
+const (
+	mfdCloexec      = 0x1
+	mfdAllowSealing = 0x2
+	fAddSeals       = 1033
+	fSealSeal       = 0x1
+	fSealShrink     = 0x2
+	fSealGrow       = 0x4
+	fSealWrite      = 0x8
+	fSealAll        = fSealSeal | fSealShrink | fSealGrow | fSealWrite
+)
+
+// journalFileDirs are the directories journald accepts unsealed files from, tried in order
+var journalFileDirs = []string{"/dev/shm", "/tmp", "/var/tmp"}
+
+// openJournal creates an unbound datagram socket, each entry is addressed to the journal socket
+func openJournal() (*net.UnixConn, error) {
+	fd, err := syscall.Socket(syscall.AF_UNIX, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	file := os.NewFile(uintptr(fd), "journal")
+	defer file.Close()
+
+	conn, err := net.FileConn(file)
+	if err != nil {
+		return nil, err
+	}
+	return conn.(*net.UnixConn), nil
+}
+
+// writeJournal sends the data as a datagram, or through a file if it is too large for one
+func writeJournal(conn *net.UnixConn, addr *net.UnixAddr, data []byte) error {
+	_, _, err := conn.WriteMsgUnix(data, nil, addr)
+	if err == nil {
+		return nil
+	}
+
+	if !errors.Is(err, syscall.EMSGSIZE) && !errors.Is(err, syscall.ENOBUFS) {
+		return err
+	}
+
+	file, err := journalFile(data)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, _, err = conn.WriteMsgUnix(nil, syscall.UnixRights(int(file.Fd())), addr)
+	return err
+}
+
+// journalFile returns a file holding the data, a sealed memfd if the kernel supports them, or an unlinked file in
+// one of journalFileDirs, like systemd's own client
+func journalFile(data []byte) (*os.File, error) {
+	file, err := sealedJournalFile(data)
+	if err == nil {
+		return file, nil
+	}
+
+	for _, dir := range journalFileDirs {
+		file, err = unlinkedJournalFile(dir, data)
+		if err == nil {
+			return file, nil
+		}
+	}
+	return nil, err
+}
+
+// sealedJournalFile writes the data to a memfd and seals it, so journald knows it can't change
+func sealedJournalFile(data []byte) (*os.File, error) {
+	call, ok := memfdCreateCalls[runtime.GOARCH]
+	if !ok {
+		return nil, syscall.ENOSYS
+	}
+
+	name, err := syscall.BytePtrFromString("lg-journal")
+	if err != nil {
+		return nil, err
+	}
+	fd, _, errno := syscall.Syscall(call, uintptr(unsafe.Pointer(name)), mfdCloexec|mfdAllowSealing, 0)
+	if errno != 0 {
+		return nil, errno
+	}
+	file := os.NewFile(fd, "lg-journal")
+
+	_, err = file.Write(data)
+	if err == nil {
+		_, _, errno = syscall.Syscall(syscall.SYS_FCNTL, fd, fAddSeals, fSealAll)
+		if errno != 0 {
+			err = errno
+		}
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// unlinkedJournalFile writes the data to a file in the directory that is removed as soon as it is created
+func unlinkedJournalFile(dir string, data []byte) (*os.File, error) {
+	file, err := os.CreateTemp(dir, "lg-journal-")
+	if err != nil {
+		return nil, err
+	}
+	os.Remove(file.Name())
+
+	_, err = file.Write(data)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return file, nil
+}
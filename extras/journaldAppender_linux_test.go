@@ -0,0 +1,158 @@
+package extras
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/sasbury/lg"
+	"github.com/stretchr/testify/require"
+)
+
+// readJournal reads a datagram from the stand-in socket
+func readJournal(t *testing.T, conn *net.UnixConn) []journalField {
+	fields, _ := readJournalFile(t, conn)
+	return fields
+}
+
+// readJournalFile reads a datagram from the stand-in socket, reading the file if one was passed instead,
+// returns true if a file was passed
+func readJournalFile(t *testing.T, conn *net.UnixConn) ([]journalField, bool) {
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 1<<20)
+	oob := make([]byte, syscall.CmsgSpace(4))
+	n, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+	require.NoError(t, err)
+
+	if oobn == 0 {
+		return decodeJournal(t, buf[:n]), false
+	}
+
+	require.Equal(t, 0, n)
+	messages, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	require.NoError(t, err)
+	fds, err := syscall.ParseUnixRights(&messages[0])
+	require.NoError(t, err)
+	require.Len(t, fds, 1)
+
+	file := os.NewFile(uintptr(fds[0]), "journal")
+	defer file.Close()
+	info, err := file.Stat()
+	require.NoError(t, err)
+	data := make([]byte, info.Size())
+	_, err = file.ReadAt(data, 0)
+	require.NoError(t, err)
+	return decodeJournal(t, data), true
+}
+
+func listenJournal(t *testing.T, path string) *net.UnixConn {
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	require.NoError(t, err)
+	return conn
+}
+
+func TestJournaldAppender(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "socket")
+	listener := listenJournal(t, path)
+	defer listener.Close()
+
+	appender, err := NewJournaldAppender(JournaldOptions{Socket: path, Identifier: "myapp"})
+	require.NoError(t, err)
+	defer appender.Close()
+
+	logger := lg.NewLoggerWithEntryAppender(appender.Append)
+	logger.WithTags("server").With("user_id", 42).Printf("hello %s", "world")
+
+	require.Equal(t, []journalField{
+		{"MESSAGE", "hello world"},
+		{"PRIORITY", "6"},
+		{"SYSLOG_IDENTIFIER", "myapp"},
+		{"LG_TAGS", "server"},
+		{"USER_ID", "42"},
+	}, readJournal(t, listener))
+
+	require.NoError(t, appender.Log("plain"))
+	require.Equal(t, []journalField{
+		{"MESSAGE", "plain"},
+		{"PRIORITY", "6"},
+		{"SYSLOG_IDENTIFIER", "myapp"},
+	}, readJournal(t, listener))
+
+	require.NoError(t, appender.Close())
+	require.Equal(t, ErrAppenderClosed, appender.Log("closed"))
+}
+
+func TestJournaldLargeMessage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "socket")
+	listener := listenJournal(t, path)
+	defer listener.Close()
+
+	appender, err := NewJournaldAppender(JournaldOptions{Socket: path})
+	require.NoError(t, err)
+	defer appender.Close()
+
+	large := strings.Repeat("x", 4<<20)
+	require.NoError(t, appender.Log(large))
+
+	fields, passed := readJournalFile(t, listener)
+	require.True(t, passed)
+	require.Equal(t, "MESSAGE", fields[0].name)
+	require.Equal(t, large, fields[0].value)
+}
+
+func TestJournalFile(t *testing.T) {
+	data := []byte("MESSAGE=large\n")
+
+	file, err := journalFile(data)
+	require.NoError(t, err)
+	defer file.Close()
+
+	// F_GET_SEALS
+	seals, _, errno := syscall.Syscall(syscall.SYS_FCNTL, file.Fd(), 1034, 0)
+	if errno == 0 {
+		require.Equal(t, uintptr(fSealAll), seals)
+		_, err = file.Write([]byte("more"))
+		require.Error(t, err)
+	}
+
+	read := make([]byte, len(data))
+	_, err = file.ReadAt(read, 0)
+	require.NoError(t, err)
+	require.Equal(t, data, read)
+
+	unlinked, err := unlinkedJournalFile(t.TempDir(), data)
+	require.NoError(t, err)
+	defer unlinked.Close()
+	info, err := unlinked.Stat()
+	require.NoError(t, err)
+	require.Equal(t, int64(len(data)), info.Size())
+	_, err = os.Stat(filepath.Join(filepath.Dir(unlinked.Name()), info.Name()))
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestJournaldRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "socket")
+	listener := listenJournal(t, path)
+
+	appender, err := NewJournaldAppender(JournaldOptions{Socket: path})
+	require.NoError(t, err)
+	defer appender.Close()
+
+	// journald restarts, creating a new socket at the same path
+	listener.Close()
+	os.Remove(path)
+	listener = listenJournal(t, path)
+	defer listener.Close()
+
+	require.NoError(t, appender.Log("after restart"))
+	require.Equal(t, "after restart", readJournal(t, listener)[0].value)
+}
+
+func TestJournaldMissingSocket(t *testing.T) {
+	_, err := NewJournaldAppender(JournaldOptions{Socket: filepath.Join(t.TempDir(), "missing")})
+	require.Error(t, err)
+}
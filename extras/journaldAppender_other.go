@@ -0,0 +1,20 @@
+//go:build !linux
+
+package extras
+
+import (
+	"net"
+)
+
+// journaldSupported is true on platforms where the native protocol is implemented
+const journaldSupported = false
+
+// openJournal returns ErrJournaldUnsupported on platforms other than linux
+func openJournal() (*net.UnixConn, error) {
+	return nil, ErrJournaldUnsupported
+}
+
+// writeJournal returns ErrJournaldUnsupported on platforms other than linux
+func writeJournal(conn *net.UnixConn, addr *net.UnixAddr, data []byte) error {
+	return ErrJournaldUnsupported
+}
@@ -0,0 +1,84 @@
+package extras
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sasbury/lg"
+	"github.com/stretchr/testify/require"
+)
+
+// journalField is a decoded native protocol field
+type journalField struct {
+	name  string
+	value string
+}
+
+// decodeJournal parses the native protocol
+func decodeJournal(t *testing.T, data []byte) []journalField {
+	var fields []journalField
+	for len(data) > 0 {
+		end := bytes.IndexAny(data, "=\n")
+		require.True(t, end > 0)
+		name := string(data[:end])
+
+		if data[end] == '=' {
+			data = data[end+1:]
+			nl := bytes.IndexByte(data, '\n')
+			require.True(t, nl >= 0)
+			fields = append(fields, journalField{name, string(data[:nl])})
+			data = data[nl+1:]
+			continue
+		}
+
+		data = data[end+1:]
+		size := binary.LittleEndian.Uint64(data[:8])
+		data = data[8:]
+		fields = append(fields, journalField{name, string(data[:size])})
+		require.Equal(t, byte('\n'), data[size])
+		data = data[size+1:]
+	}
+	return fields
+}
+
+func TestJournalFieldName(t *testing.T) {
+	require.Equal(t, "USER_ID", journalFieldName("user_id"))
+	require.Equal(t, "REQUEST_ID", journalFieldName("request-id"))
+	require.Equal(t, "HIDDEN", journalFieldName("__hidden"))
+	require.Equal(t, "LG_1ST", journalFieldName("1st"))
+	require.Equal(t, "LG_", journalFieldName("__"))
+	require.Equal(t, "LG_MESSAGE", journalFieldName("message"))
+	require.Equal(t, "LG_LG_TAGS", journalFieldName("lg.tags"))
+	require.Len(t, journalFieldName(strings.Repeat("a", 100)), 64)
+}
+
+func TestJournalEncode(t *testing.T) {
+	appender := &JournaldAppender{opts: JournaldOptions{Identifier: "myapp", InfoPriority: 6, DebugPriority: 7}}
+
+	data := appender.encode(&lg.Entry{
+		Debug:  true,
+		Tags:   []string{"server", "db"},
+		Format: "line one\nline %s",
+		Args:   []interface{}{"two"},
+		Fields: lg.Fields{lg.Int("user_id", 42), lg.Err(errors.New("timeout")), lg.String("message", "clash")},
+		Caller: &lg.Caller{File: "/src/main.go", Line: 20, Function: "main.main"},
+	})
+
+	require.Equal(t, []journalField{
+		{"MESSAGE", "line one\nline two"},
+		{"PRIORITY", "7"},
+		{"SYSLOG_IDENTIFIER", "myapp"},
+		{"LG_TAGS", "server,db"},
+		{"CODE_FILE", "/src/main.go"},
+		{"CODE_LINE", "20"},
+		{"CODE_FUNC", "main.main"},
+		{"USER_ID", "42"},
+		{"ERROR", "timeout"},
+		{"LG_MESSAGE", "clash"},
+	}, decodeJournal(t, data))
+
+	require.Equal(t, "MESSAGE=hi\nPRIORITY=6\nSYSLOG_IDENTIFIER=myapp\n", string(appender.encode(&lg.Entry{Format: "hi"})))
+}
@@ -93,6 +93,16 @@ func (appender *RollingFileAppender) Close() error {
 	return appender.close()
 }
 
+// Reopen closes the current file after flushing any buffered data, the next entry will open
+// the file again, creating it if it was moved. This allows the appender to cooperate with external
+// tools like logrotate.
+// Locks the appender
+func (appender *RollingFileAppender) Reopen() error {
+	appender.Lock()
+	defer appender.Unlock()
+	return appender.close()
+}
+
 // close the current writer and file, assumes the lock is held
 func (appender *RollingFileAppender) close() error {
 	var err error
@@ -79,3 +79,31 @@ func TestRollingAppenderNew(t *testing.T) {
 	require.Equal(t, app.maxFileSize, int64(1024), "max filesize defaults to 1024")
 	require.Equal(t, app.currentFileName(), fmt.Sprintf("%s.%s", filepath, "log"), "current file name is always prefix.suffix")
 }
+
+func TestRollingAppenderReopen(t *testing.T) {
+	filepath := path.Join(os.TempDir(), "reopentest")
+	app := NewRollingFileAppender(filepath, "log", int64(2048), 1)
+	current := fmt.Sprintf("%s.log", filepath)
+	moved := fmt.Sprintf("%s.moved.log", filepath)
+	os.Remove(current)
+	os.Remove(moved)
+
+	var reopener lg.Reopener = app
+	require.Nil(t, app.Log("one"))
+	require.Nil(t, os.Rename(current, moved))
+	f, err := os.Create(current) // like logrotate's create option
+	require.Nil(t, err)
+	f.Close()
+	require.Nil(t, app.Log("two")) // still writing to the moved file
+	require.Nil(t, reopener.Reopen())
+	require.Nil(t, app.Log("three"))
+	app.Close()
+
+	info, err := os.Stat(moved)
+	require.Nil(t, err)
+	require.Equal(t, int64(8), info.Size(), "moved file should have the first two entries")
+
+	info, err = os.Stat(current)
+	require.Nil(t, err)
+	require.Equal(t, int64(6), info.Size(), "reopened file should have the last entry")
+}
@@ -0,0 +1,284 @@
+package extras
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sasbury/lg"
+)
+
+// DefaultSamplingInterval is the interval used when SamplingOptions.Interval isn't set
+const DefaultSamplingInterval = time.Second
+
+// SamplingOptions configures a SamplingAppender, each set of tags is sampled and rate limited on its own
+type SamplingOptions struct {
+	// Interval is how often sampling starts over and summaries are written, defaults to DefaultSamplingInterval
+	Interval time.Duration
+	// First is the number of entries written each interval before sampling starts, 0 turns sampling off
+	First int
+	// Thereafter writes every Mth entry after the first ones, 0 suppresses them all
+	Thereafter int
+	// Rate is the number of entries per second allowed through the token bucket, 0 turns rate limiting off
+	Rate float64
+	// Burst is the size of the token bucket, defaults to Rate rounded up
+	Burst int
+	// Formatter formats entries passed to Append and the summaries, defaults to lg.SimpleFormat
+	Formatter lg.LogFormatter
+	// Clock returns the current time, defaults to time.Now
+	Clock func() time.Time
+}
+
+// samplingState tracks one set of tags
+type samplingState struct {
+	tags       []string
+	start      time.Time
+	count      int
+	tokens     float64
+	refilled   time.Time
+	suppressed uint64
+}
+
+/*
+SamplingAppender is middleware that keeps a hot loop from flooding the next appender. Entries are grouped by their
+tags, and for each set of tags the first entries in an interval are written, then every Mth one. Entries that get
+through sampling are also passed through a token bucket, if Rate is set.
+
+Suppressed entries are counted, and when the interval ends a summary like
+
+	suppressed 1234 entries for [server db]
+
+is written to the next appender. Summaries are written by a background goroutine and by Close, so a tag set that
+goes quiet still gets one. Use Append as an EntryAppender, Log can be used as a LogAppender, in which case all of
+the entries are sampled together as untagged entries.
+*/
+type SamplingAppender struct {
+	sync.Mutex
+	next   lg.LogAppender
+	opts   SamplingOptions
+	states map[string]*samplingState
+	closed bool
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// NewSamplingAppender returns a new sampling appender with the provided next appender, and starts the goroutine
+// that writes summaries
+func NewSamplingAppender(next lg.LogAppender, opts SamplingOptions) *SamplingAppender {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultSamplingInterval
+	}
+	if opts.Burst <= 0 && opts.Rate > 0 {
+		opts.Burst = int(opts.Rate)
+		if float64(opts.Burst) < opts.Rate {
+			opts.Burst++
+		}
+	}
+	if opts.Formatter == nil {
+		opts.Formatter = lg.SimpleFormat
+	}
+	if opts.Clock == nil {
+		opts.Clock = time.Now
+	}
+
+	sa := &SamplingAppender{
+		next:   next,
+		opts:   opts,
+		states: map[string]*samplingState{},
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	go sa.run()
+
+	return sa
+}
+
+// Append is the sampling appender's implementation of EntryAppender
+func (sa *SamplingAppender) Append(entry *lg.Entry) error {
+	return sa.sample(entry.Tags, func() string {
+		return entry.FormatWith(sa.opts.Formatter)
+	})
+}
+
+// Log is the sampling appender's implementation of LogAppender, entries are sampled as untagged entries
+func (sa *SamplingAppender) Log(entry string) error {
+	return sa.sample(nil, func() string {
+		return entry
+	})
+}
+
+// sample decides if the entry is written, format is only called for entries that are. If the tag set's interval
+// is over its summary is written first.
+func (sa *SamplingAppender) sample(tags []string, format func() string) error {
+	sa.Lock()
+	if sa.closed {
+		sa.Unlock()
+		return ErrAppenderClosed
+	}
+
+	now := sa.opts.Clock()
+	key := strings.Join(tags, "\x00")
+	state := sa.states[key]
+	if state == nil {
+		state = &samplingState{
+			tags:     append([]string{}, tags...),
+			start:    now,
+			tokens:   float64(sa.opts.Burst),
+			refilled: now,
+		}
+		sa.states[key] = state
+	}
+
+	var summary string
+	if now.Sub(state.start) >= sa.opts.Interval {
+		summary = sa.reset(state, now)
+	}
+
+	allowed := sa.allow(state, now)
+	if !allowed {
+		state.suppressed++
+	}
+	next := sa.next
+	sa.Unlock()
+
+	if summary != "" {
+		if err := next(summary); err != nil {
+			return err
+		}
+	}
+	if !allowed {
+		return nil
+	}
+	return next(format())
+}
+
+// allow applies sampling and then the token bucket, the lock is held
+func (sa *SamplingAppender) allow(state *samplingState, now time.Time) bool {
+	state.count++
+	if sa.opts.First > 0 && state.count > sa.opts.First {
+		if sa.opts.Thereafter <= 0 || (state.count-sa.opts.First)%sa.opts.Thereafter != 0 {
+			return false
+		}
+	}
+
+	if sa.opts.Rate > 0 {
+		state.tokens += now.Sub(state.refilled).Seconds() * sa.opts.Rate
+		if state.tokens > float64(sa.opts.Burst) {
+			state.tokens = float64(sa.opts.Burst)
+		}
+		state.refilled = now
+		if state.tokens < 1 {
+			return false
+		}
+		state.tokens--
+	}
+
+	return true
+}
+
+// reset starts a new interval for the tag set and returns its summary, or an empty string if nothing was
+// suppressed, the lock is held
+func (sa *SamplingAppender) reset(state *samplingState, now time.Time) string {
+	var summary string
+	if state.suppressed > 0 {
+		summary = sa.summary(state, now)
+	}
+	state.start = now
+	state.count = 0
+	state.suppressed = 0
+	return summary
+}
+
+// summary formats the summary entry for the tag set, the lock is held
+func (sa *SamplingAppender) summary(state *samplingState, now time.Time) string {
+	entry := &lg.Entry{
+		Time:   now,
+		Format: "suppressed %d entries for [%s]",
+		Args:   []interface{}{state.suppressed, strings.Join(state.tags, " ")},
+	}
+	if len(state.tags) == 0 {
+		entry.Format = "suppressed %d untagged entries"
+		entry.Args = entry.Args[:1]
+	}
+	return entry.FormatWith(sa.opts.Formatter)
+}
+
+// summarize writes the summaries, sorted by tags, for tag sets whose interval is over, or all of them if all is
+// true. Tag sets that had no entries in their last interval are forgotten.
+func (sa *SamplingAppender) summarize(all bool) error {
+	var summaries []string
+
+	sa.Lock()
+	now := sa.opts.Clock()
+	keys := make([]string, 0, len(sa.states))
+	for key := range sa.states {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		state := sa.states[key]
+		if !all && now.Sub(state.start) < sa.opts.Interval {
+			continue
+		}
+		if state.count == 0 {
+			delete(sa.states, key)
+			continue
+		}
+		if summary := sa.reset(state, now); summary != "" {
+			summaries = append(summaries, summary)
+		}
+	}
+	next := sa.next
+	sa.Unlock()
+
+	var errors []error
+	for _, summary := range summaries {
+		if err := next(summary); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	if len(errors) > 0 {
+		return BranchingError{
+			Children: errors,
+		}
+	}
+
+	return nil
+}
+
+// run writes summaries every interval until the appender is closed
+func (sa *SamplingAppender) run() {
+	defer close(sa.done)
+
+	ticker := time.NewTicker(sa.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			sa.summarize(false)
+		case <-sa.stop:
+			return
+		}
+	}
+}
+
+// Close stops the summary goroutine and writes the summaries for any entries suppressed in the current intervals,
+// entries logged after Close return ErrAppenderClosed. The next appender isn't closed.
+func (sa *SamplingAppender) Close() error {
+	sa.Lock()
+	if sa.closed {
+		sa.Unlock()
+		return nil
+	}
+	sa.closed = true
+	close(sa.stop)
+	sa.Unlock()
+
+	<-sa.done
+
+	return sa.summarize(true)
+}
@@ -0,0 +1,147 @@
+package extras
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sasbury/lg"
+	"github.com/stretchr/testify/require"
+)
+
+// testClock is a clock that only moves when it is told to
+type testClock struct {
+	sync.Mutex
+	now time.Time
+}
+
+func (tc *testClock) Now() time.Time {
+	tc.Lock()
+	defer tc.Unlock()
+	return tc.now
+}
+
+func (tc *testClock) Add(d time.Duration) {
+	tc.Lock()
+	tc.now = tc.now.Add(d)
+	tc.Unlock()
+}
+
+func newTestClock() *testClock {
+	return &testClock{now: time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)}
+}
+
+func taggedEntry(msg string, tags ...string) *lg.Entry {
+	return &lg.Entry{Format: msg, Tags: tags}
+}
+
+func TestSamplingFirstThenEvery(t *testing.T) {
+	clock := newTestClock()
+	array := &lg.ArrayAppender{}
+	sa := NewSamplingAppender(array.Log, SamplingOptions{
+		Interval:   time.Hour,
+		First:      2,
+		Thereafter: 3,
+		Formatter:  lg.MinimalFormat,
+		Clock:      clock.Now,
+	})
+
+	for i := 1; i <= 10; i++ {
+		require.NoError(t, sa.Append(taggedEntry(fmt.Sprint(i), "server", "db")))
+	}
+	require.NoError(t, sa.Append(taggedEntry("other", "server")))
+	require.Equal(t, []string{"1", "2", "5", "8", "other"}, array.Entries)
+
+	// a new interval starts sampling over, and writes the summary for the last one first
+	clock.Add(time.Hour)
+	require.NoError(t, sa.Append(taggedEntry("11", "server", "db")))
+	require.Equal(t, []string{"suppressed 6 entries for [server db]", "11"}, array.Entries[5:])
+
+	require.NoError(t, sa.Close())
+	require.Len(t, array.Entries, 7)
+	require.Equal(t, ErrAppenderClosed, sa.Log("closed"))
+	require.NoError(t, sa.Close())
+}
+
+func TestSamplingSuppressAll(t *testing.T) {
+	array := &lg.ArrayAppender{}
+	sa := NewSamplingAppender(array.Log, SamplingOptions{First: 1, Formatter: lg.MinimalFormat})
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, sa.Log(fmt.Sprint(i)))
+		require.NoError(t, sa.Append(taggedEntry(fmt.Sprint(i), "a")))
+	}
+
+	require.NoError(t, sa.Close())
+	require.Equal(t, []string{"0", "0", "suppressed 4 untagged entries", "suppressed 4 entries for [a]"}, array.Entries)
+}
+
+func TestSamplingRate(t *testing.T) {
+	clock := newTestClock()
+	array := &lg.ArrayAppender{}
+	sa := NewSamplingAppender(array.Log, SamplingOptions{
+		Interval:  time.Hour,
+		Rate:      2,
+		Formatter: lg.MinimalFormat,
+		Clock:     clock.Now,
+	})
+	defer sa.Close()
+
+	// the bucket starts full
+	for i := 0; i < 4; i++ {
+		require.NoError(t, sa.Append(taggedEntry(fmt.Sprint(i), "a")))
+	}
+	require.Equal(t, []string{"0", "1"}, array.Entries)
+
+	// each tag set has its own bucket
+	require.NoError(t, sa.Append(taggedEntry("b", "b")))
+
+	clock.Add(500 * time.Millisecond)
+	require.NoError(t, sa.Append(taggedEntry("4", "a")))
+	require.NoError(t, sa.Append(taggedEntry("5", "a")))
+
+	// the bucket never holds more than the burst
+	clock.Add(time.Minute)
+	for i := 6; i < 10; i++ {
+		require.NoError(t, sa.Append(taggedEntry(fmt.Sprint(i), "a")))
+	}
+	require.Equal(t, []string{"0", "1", "b", "4", "6", "7"}, array.Entries)
+}
+
+func TestSamplingSummaryTimer(t *testing.T) {
+	array := &lg.ArrayAppender{}
+	sa := NewSamplingAppender(array.Log, SamplingOptions{
+		Interval:  10 * time.Millisecond,
+		First:     1,
+		Formatter: lg.MinimalFormat,
+	})
+	defer sa.Close()
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, sa.Append(taggedEntry("quiet", "x")))
+	}
+
+	require.Eventually(t, func() bool {
+		array.Lock()
+		defer array.Unlock()
+		return len(array.Entries) == 2
+	}, 5*time.Second, 5*time.Millisecond)
+	array.Lock()
+	require.Equal(t, []string{"quiet", "suppressed 2 entries for [x]"}, array.Entries)
+	array.Unlock()
+
+	// quiet tag sets are forgotten once an interval passes without entries
+	require.Eventually(t, func() bool {
+		sa.Lock()
+		defer sa.Unlock()
+		return len(sa.states) == 0
+	}, 5*time.Second, 5*time.Millisecond)
+}
+
+func TestSamplingNextError(t *testing.T) {
+	sa := NewSamplingAppender(BadAppender, SamplingOptions{First: 1})
+	require.Error(t, sa.Log("first"))
+	require.NoError(t, sa.Log("suppressed"))
+	require.Error(t, sa.Close())
+}
@@ -0,0 +1,412 @@
+package extras
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sasbury/lg"
+)
+
+// SyslogFormat selects the syslog message format
+type SyslogFormat int
+
+// The syslog formats, SyslogAuto uses RFC 3164 for the local socket and RFC 5424 for everything else
+const (
+	SyslogAuto SyslogFormat = iota
+	SyslogRFC5424
+	SyslogRFC3164
+)
+
+// A few of the syslog facilities, see RFC 5424 for the rest
+const (
+	SyslogUser   = 1
+	SyslogDaemon = 3
+	SyslogLocal0 = 16
+	SyslogLocal1 = 17
+	SyslogLocal2 = 18
+	SyslogLocal3 = 19
+	SyslogLocal4 = 20
+	SyslogLocal5 = 21
+	SyslogLocal6 = 22
+	SyslogLocal7 = 23
+)
+
+// The syslog severities used for info and debug entries by default
+const (
+	SyslogInformational = 6
+	SyslogDebug         = 7
+)
+
+// DefaultSyslogSocket is the local syslog socket used when the network is empty or unix without an address
+const DefaultSyslogSocket = "/dev/log"
+
+// DefaultStructuredDataID is the SD-ID used for tags and fields in RFC 5424 messages
+const DefaultStructuredDataID = "lg@32473"
+
+// SyslogOptions configures a SyslogAppender, empty settings use the defaults
+type SyslogOptions struct {
+	Network string // "udp", "tcp", "unix" or "unixgram", empty uses the local socket
+	Address string // host:port for udp and tcp, the socket path for unix, defaults to DefaultSyslogSocket
+	Format  SyslogFormat
+
+	Facility      int // defaults to SyslogUser
+	InfoSeverity  int // defaults to SyslogInformational
+	DebugSeverity int // defaults to SyslogDebug
+
+	Hostname string // defaults to os.Hostname
+	AppName  string // defaults to the base name of the program
+	// TagAsAppName uses an entry's first tag as the app name, entries without tags use AppName
+	TagAsAppName bool
+	// StructuredDataID is the SD-ID for the tags and fields in RFC 5424 messages, defaults to DefaultStructuredDataID
+	StructuredDataID string
+
+	DialTimeout  time.Duration // defaults to 5 seconds
+	WriteTimeout time.Duration // defaults to 5 seconds, not used for udp
+}
+
+/*
+SyslogAppender sends entries to a syslog server over udp, tcp or a unix socket.
+
+Messages are written in RFC 5424 or RFC 3164 format. Over tcp they are framed with octet counting, over a unix
+stream socket they end with a new line, and over udp or a unix datagram socket each message is one datagram.
+The debug flag picks the severity. In RFC 5424 messages the tags and fields are written as structured data,
+in RFC 3164 messages they are added to the message text. Stacks are added to the message text in both.
+
+The connection is made when the appender is created. If a write fails the appender reconnects and tries
+once more, if that fails the error is returned and the next entry tries to reconnect again.
+
+Use Append as an EntryAppender so that the tags, debug flag and fields are available, Log can be used as a
+LogAppender, in which case every entry is sent with the info severity.
+*/
+type SyslogAppender struct {
+	sync.Mutex
+	opts    SyslogOptions
+	local   bool
+	pid     string
+	conn    net.Conn
+	network string // the network conn was dialed with
+	closed  bool
+}
+
+// NewSyslogAppender connects to the syslog server, an error is returned if the connection fails
+func NewSyslogAppender(opts SyslogOptions) (*SyslogAppender, error) {
+	local := false
+	switch opts.Network {
+	case "", "unix", "unixgram":
+		local = true
+		if opts.Address == "" {
+			opts.Address = DefaultSyslogSocket
+		}
+	case "udp", "udp4", "udp6", "tcp", "tcp4", "tcp6":
+		if opts.Address == "" {
+			return nil, errors.New("syslog appender requires an address for " + opts.Network)
+		}
+	default:
+		return nil, errors.New("syslog appender doesn't support the network " + opts.Network)
+	}
+
+	if opts.Format == SyslogAuto {
+		if local {
+			opts.Format = SyslogRFC3164
+		} else {
+			opts.Format = SyslogRFC5424
+		}
+	}
+	if opts.Facility == 0 {
+		opts.Facility = SyslogUser
+	}
+	if opts.InfoSeverity == 0 {
+		opts.InfoSeverity = SyslogInformational
+	}
+	if opts.DebugSeverity == 0 {
+		opts.DebugSeverity = SyslogDebug
+	}
+	if opts.Hostname == "" {
+		opts.Hostname, _ = os.Hostname()
+	}
+	if opts.AppName == "" {
+		opts.AppName = filepath.Base(os.Args[0])
+	}
+	if opts.StructuredDataID == "" {
+		opts.StructuredDataID = DefaultStructuredDataID
+	}
+	if opts.DialTimeout <= 0 {
+		opts.DialTimeout = 5 * time.Second
+	}
+	if opts.WriteTimeout <= 0 {
+		opts.WriteTimeout = 5 * time.Second
+	}
+
+	appender := &SyslogAppender{
+		opts:  opts,
+		local: local,
+		pid:   strconv.Itoa(os.Getpid()),
+	}
+
+	appender.Lock()
+	defer appender.Unlock()
+
+	err := appender.connect()
+	if err != nil {
+		return nil, err
+	}
+
+	return appender, nil
+}
+
+// Append is the syslog appender's implementation of EntryAppender
+func (sa *SyslogAppender) Append(entry *lg.Entry) error {
+	return sa.send(entry)
+}
+
+// Log is the syslog appender's implementation of LogAppender, entries are sent with the info severity
+func (sa *SyslogAppender) Log(entry string) error {
+	return sa.send(&lg.Entry{
+		Time:   time.Now(),
+		Format: "%s",
+		Args:   []interface{}{entry},
+	})
+}
+
+// Reopen closes the connection, the next entry reconnects, so that the appender can be used with lg.HandleSignals
+func (sa *SyslogAppender) Reopen() error {
+	sa.Lock()
+	defer sa.Unlock()
+	return sa.disconnect()
+}
+
+// Close closes the connection, entries logged after Close return ErrAppenderClosed
+func (sa *SyslogAppender) Close() error {
+	sa.Lock()
+	defer sa.Unlock()
+	sa.closed = true
+	return sa.disconnect()
+}
+
+func (sa *SyslogAppender) send(entry *lg.Entry) error {
+	var msg string
+	if sa.opts.Format == SyslogRFC3164 {
+		msg = sa.formatRFC3164(entry)
+	} else {
+		msg = sa.formatRFC5424(entry)
+	}
+
+	sa.Lock()
+	defer sa.Unlock()
+
+	if sa.closed {
+		return ErrAppenderClosed
+	}
+
+	err := sa.write(msg)
+	if err == nil {
+		return nil
+	}
+
+	// reconnect and try once more
+	sa.disconnect()
+	return sa.write(msg)
+}
+
+// write sends the message, connecting if necessary, the lock is held
+func (sa *SyslogAppender) write(msg string) error {
+	if sa.conn == nil {
+		err := sa.connect()
+		if err != nil {
+			return err
+		}
+	}
+
+	switch sa.network {
+	case "tcp", "tcp4", "tcp6":
+		msg = strconv.Itoa(len(msg)) + " " + msg
+		sa.conn.SetWriteDeadline(time.Now().Add(sa.opts.WriteTimeout))
+	case "unix":
+		msg = msg + "\n"
+		sa.conn.SetWriteDeadline(time.Now().Add(sa.opts.WriteTimeout))
+	case "unixgram":
+		sa.conn.SetWriteDeadline(time.Now().Add(sa.opts.WriteTimeout))
+	}
+
+	_, err := sa.conn.Write([]byte(msg))
+	return err
+}
+
+// connect dials the server, the local socket is tried as a datagram socket and then a stream socket, the lock is held
+func (sa *SyslogAppender) connect() error {
+	if sa.opts.Network != "" {
+		conn, err := net.DialTimeout(sa.opts.Network, sa.opts.Address, sa.opts.DialTimeout)
+		if err != nil {
+			return err
+		}
+		sa.conn, sa.network = conn, sa.opts.Network
+		return nil
+	}
+
+	var err error
+	for _, network := range []string{"unixgram", "unix"} {
+		var conn net.Conn
+		conn, err = net.DialTimeout(network, sa.opts.Address, sa.opts.DialTimeout)
+		if err == nil {
+			sa.conn, sa.network = conn, network
+			return nil
+		}
+	}
+	return err
+}
+
+// disconnect closes the connection if there is one, the lock is held
+func (sa *SyslogAppender) disconnect() error {
+	if sa.conn == nil {
+		return nil
+	}
+	err := sa.conn.Close()
+	sa.conn = nil
+	return err
+}
+
+// priority returns the <PRI> part of the header
+func (sa *SyslogAppender) priority(entry *lg.Entry) string {
+	severity := sa.opts.InfoSeverity
+	if entry.Debug {
+		severity = sa.opts.DebugSeverity
+	}
+	return "<" + strconv.Itoa(sa.opts.Facility*8+severity) + ">"
+}
+
+// appName returns the app name for the entry
+func (sa *SyslogAppender) appName(entry *lg.Entry) string {
+	if sa.opts.TagAsAppName && len(entry.Tags) > 0 {
+		return entry.Tags[0]
+	}
+	return sa.opts.AppName
+}
+
+// formatRFC5424 formats the entry as:
+//
+//	<PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID [SD-ID tags="a,b" key="value"] MSG
+func (sa *SyslogAppender) formatRFC5424(entry *lg.Entry) string {
+	b := &strings.Builder{}
+	b.WriteString(sa.priority(entry))
+	b.WriteString("1 ")
+	b.WriteString(entry.Time.Format("2006-01-02T15:04:05.000000Z07:00"))
+	b.WriteString(" ")
+	b.WriteString(headerField(sa.opts.Hostname, 255))
+	b.WriteString(" ")
+	b.WriteString(headerField(sa.appName(entry), 48))
+	b.WriteString(" ")
+	b.WriteString(sa.pid)
+	b.WriteString(" - ")
+
+	if len(entry.Tags) == 0 && len(entry.Fields) == 0 {
+		b.WriteString("-")
+	} else {
+		b.WriteString("[")
+		b.WriteString(sa.opts.StructuredDataID)
+		if len(entry.Tags) > 0 {
+			writeParam(b, "tags", strings.Join(entry.Tags, ","))
+		}
+		for _, f := range entry.Fields {
+			writeParam(b, f.Key, fmt.Sprint(f.Value))
+		}
+		b.WriteString("]")
+	}
+
+	msg := messageWithStack(entry)
+	if msg != "" {
+		b.WriteString(" ")
+		b.WriteString(msg)
+	}
+
+	return b.String()
+}
+
+// formatRFC3164 formats the entry as, the hostname is left out for the local socket:
+//
+//	<PRI>Jan _2 15:04:05 HOSTNAME TAG[PID]: [tags] MSG key=value
+func (sa *SyslogAppender) formatRFC3164(entry *lg.Entry) string {
+	b := &strings.Builder{}
+	b.WriteString(sa.priority(entry))
+	b.WriteString(entry.Time.Format(time.Stamp))
+	b.WriteString(" ")
+	if !sa.local {
+		b.WriteString(headerField(sa.opts.Hostname, 255))
+		b.WriteString(" ")
+	}
+	b.WriteString(headerField(sa.appName(entry), 32))
+	b.WriteString("[")
+	b.WriteString(sa.pid)
+	b.WriteString("]: ")
+
+	if len(entry.Tags) > 0 {
+		b.WriteString("[")
+		b.WriteString(strings.Join(entry.Tags, ", "))
+		b.WriteString("] ")
+	}
+
+	noCaller := *entry
+	noCaller.Caller = nil
+	b.WriteString(noCaller.FormatWith(lg.MinimalFormat))
+
+	return b.String()
+}
+
+// messageWithStack renders the entry's message and any stack, without the fields
+func messageWithStack(entry *lg.Entry) string {
+	msgOnly := *entry
+	msgOnly.Fields = nil
+	msgOnly.Caller = nil
+	return msgOnly.FormatWith(lg.MinimalFormat)
+}
+
+// headerField replaces anything other than printable ASCII in a header field, an empty field is "-"
+func headerField(value string, max int) string {
+	if value == "" {
+		return "-"
+	}
+	value = strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' {
+			return '_'
+		}
+		return r
+	}, value)
+	if len(value) > max {
+		value = value[:max]
+	}
+	return value
+}
+
+// writeParam writes a structured data parameter, the name is limited to 32 characters without spaces, =, ] or ",
+// and ", \ and ] are escaped in the value
+func writeParam(b *strings.Builder, name string, value string) {
+	name = strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' || r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+		return r
+	}, name)
+	if name == "" {
+		name = "_"
+	}
+	if len(name) > 32 {
+		name = name[:32]
+	}
+
+	b.WriteString(" ")
+	b.WriteString(name)
+	b.WriteString(`="`)
+	for _, r := range value {
+		if r == '"' || r == '\\' || r == ']' {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	b.WriteString(`"`)
+}
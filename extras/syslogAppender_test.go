@@ -0,0 +1,234 @@
+package extras
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sasbury/lg"
+	"github.com/stretchr/testify/require"
+)
+
+func testEntry() *lg.Entry {
+	return &lg.Entry{
+		Time:   time.Date(2024, 1, 2, 15, 4, 5, 123456000, time.UTC),
+		Tags:   []string{"server", "db"},
+		Format: "query %s",
+		Args:   []interface{}{"failed"},
+		Fields: lg.Fields{lg.String("host", `db "1"]`), lg.Err(errors.New("timeout")), lg.String("bad key", "v")},
+	}
+}
+
+func readDatagram(t *testing.T, conn net.PacketConn) string {
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 65536)
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	return string(buf[:n])
+}
+
+func TestSyslogUDP(t *testing.T) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	appender, err := NewSyslogAppender(SyslogOptions{
+		Network:  "udp",
+		Address:  listener.LocalAddr().String(),
+		Hostname: "web 1",
+		AppName:  "myapp",
+		Facility: SyslogLocal0,
+	})
+	require.NoError(t, err)
+	defer appender.Close()
+
+	pid := strconv.Itoa(os.Getpid())
+
+	require.NoError(t, appender.Append(testEntry()))
+	require.Equal(t, `<134>1 2024-01-02T15:04:05.123456Z web_1 myapp `+pid+` - [lg@32473 tags="server,db" host="db \"1\"\]" error="timeout" bad_key="v"] query failed`,
+		readDatagram(t, listener))
+
+	entry := testEntry()
+	entry.Debug = true
+	entry.Tags = nil
+	entry.Fields = nil
+	entry.Stack = []lg.Caller{{Function: "main.main", File: "/src/main.go", Line: 20}}
+	require.NoError(t, appender.Append(entry))
+	require.Equal(t, `<135>1 2024-01-02T15:04:05.123456Z web_1 myapp `+pid+` - - query failed`+"\n\tmain.main\n\t\t/src/main.go:20",
+		readDatagram(t, listener))
+
+	require.NoError(t, appender.Log("plain"))
+	require.True(t, strings.HasPrefix(readDatagram(t, listener), "<134>1 "))
+
+	require.NoError(t, appender.Close())
+	require.Equal(t, ErrAppenderClosed, appender.Log("closed"))
+	require.NoError(t, appender.Close())
+}
+
+func TestSyslogRFC3164(t *testing.T) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	appender, err := NewSyslogAppender(SyslogOptions{
+		Network:       "udp",
+		Address:       listener.LocalAddr().String(),
+		Format:        SyslogRFC3164,
+		Hostname:      "web1",
+		TagAsAppName:  true,
+		DebugSeverity: SyslogInformational,
+	})
+	require.NoError(t, err)
+	defer appender.Close()
+
+	pid := strconv.Itoa(os.Getpid())
+
+	entry := testEntry()
+	entry.Debug = true
+	entry.Caller = &lg.Caller{File: "/src/main.go", Line: 20}
+	require.NoError(t, appender.Append(entry))
+	require.Equal(t, `<14>Jan  2 15:04:05 web1 server[`+pid+`]: [server, db] query failed host="db \"1\"]" error=timeout bad key=v`,
+		readDatagram(t, listener))
+
+	entry.Tags = nil
+	entry.Fields = nil
+	require.NoError(t, appender.Append(entry))
+	require.Equal(t, `<14>Jan  2 15:04:05 web1 `+headerField(filepath.Base(os.Args[0]), 32)+`[`+pid+`]: query failed`,
+		readDatagram(t, listener))
+}
+
+func TestSyslogTCPReconnect(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	conns := make(chan net.Conn, 10)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conns <- conn
+		}
+	}()
+
+	appender, err := NewSyslogAppender(SyslogOptions{
+		Network:  "tcp",
+		Address:  listener.Addr().String(),
+		Hostname: "web1",
+		AppName:  "myapp",
+	})
+	require.NoError(t, err)
+	defer appender.Close()
+
+	readFrame := func(r *bufio.Reader) string {
+		length, err := r.ReadString(' ')
+		require.NoError(t, err)
+		n, err := strconv.Atoi(strings.TrimSpace(length))
+		require.NoError(t, err)
+		buf := make([]byte, n)
+		_, err = io.ReadFull(r, buf)
+		require.NoError(t, err)
+		return string(buf)
+	}
+
+	first := <-conns
+	reader := bufio.NewReader(first)
+
+	entry := testEntry()
+	entry.Format = "line one\nline two"
+	entry.Args = nil
+	require.NoError(t, appender.Append(entry))
+	require.NoError(t, appender.Log("second"))
+
+	msg := readFrame(reader)
+	require.True(t, strings.HasSuffix(msg, "] line one\nline two"), msg)
+	msg = readFrame(reader)
+	require.True(t, strings.HasSuffix(msg, " - - second"), msg)
+
+	// the server drops the connection, the appender reconnects once a write fails
+	first.Close()
+
+	var second net.Conn
+	for i := 0; i < 50 && second == nil; i++ {
+		appender.Log("after")
+		select {
+		case second = <-conns:
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	require.NotNil(t, second)
+
+	reader = bufio.NewReader(second)
+	msg = readFrame(reader)
+	require.True(t, strings.HasSuffix(msg, " - - after"), msg)
+
+	// reopen closes the connection and the next entry makes a new one
+	require.NoError(t, appender.Reopen())
+	require.NoError(t, appender.Log("reopened"))
+	third := <-conns
+	msg = readFrame(bufio.NewReader(third))
+	require.True(t, strings.HasSuffix(msg, " - - reopened"), msg)
+	third.Close()
+	second.Close()
+}
+
+func TestSyslogUnixSocket(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "plan9" {
+		t.Skip("no unix sockets")
+	}
+
+	path := filepath.Join(t.TempDir(), "log")
+	listener, err := net.ListenPacket("unixgram", path)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	appender, err := NewSyslogAppender(SyslogOptions{Address: path, AppName: "myapp"})
+	require.NoError(t, err)
+	defer appender.Close()
+
+	pid := strconv.Itoa(os.Getpid())
+
+	entry := testEntry()
+	entry.Fields = nil
+	require.NoError(t, appender.Append(entry))
+	require.Equal(t, `<14>Jan  2 15:04:05 myapp[`+pid+`]: [server, db] query failed`, readDatagram(t, listener))
+
+	streamPath := filepath.Join(t.TempDir(), "stream")
+	streamListener, err := net.Listen("unix", streamPath)
+	require.NoError(t, err)
+	defer streamListener.Close()
+
+	stream, err := NewSyslogAppender(SyslogOptions{Address: streamPath, AppName: "myapp", Format: SyslogRFC5424, Hostname: "web1"})
+	require.NoError(t, err)
+	defer stream.Close()
+
+	conn, err := streamListener.Accept()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.NoError(t, stream.Log("over a stream"))
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	require.NoError(t, err)
+	require.True(t, strings.HasSuffix(line, " myapp "+pid+" - - over a stream\n"), line)
+}
+
+func TestSyslogOptionErrors(t *testing.T) {
+	_, err := NewSyslogAppender(SyslogOptions{Network: "udp"})
+	require.Error(t, err)
+
+	_, err = NewSyslogAppender(SyslogOptions{Network: "carrier-pigeon", Address: "coop"})
+	require.Error(t, err)
+
+	_, err = NewSyslogAppender(SyslogOptions{Network: "unix", Address: filepath.Join(t.TempDir(), "missing")})
+	require.Error(t, err)
+}
@@ -0,0 +1,156 @@
+package lg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Field is a key/value pair that a logger attaches to every entry it prints
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Fields is an ordered list of fields
+type Fields []Field
+
+// Any creates a field with an arbitrary value
+func Any(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// String creates a field with a string value
+func String(key string, value string) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Int creates a field with an int value
+func Int(key string, value int) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Int64 creates a field with an int64 value
+func Int64(key string, value int64) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Float64 creates a field with a float64 value
+func Float64(key string, value float64) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Bool creates a field with a bool value
+func Bool(key string, value bool) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Duration creates a field with a time.Duration value
+func Duration(key string, value time.Duration) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Err creates a field with the key "error" holding err
+func Err(err error) Field {
+	return Field{Key: "error", Value: err}
+}
+
+// toFields converts the arguments to With into fields. Each argument can be a Field, Fields or a key
+// followed by its value. Keys that are not strings are converted with fmt.Sprint, a key without a value
+// gets a nil value.
+func toFields(kv []interface{}) Fields {
+	fields := make(Fields, 0, len(kv)/2+1)
+	for i := 0; i < len(kv); i++ {
+		switch v := kv[i].(type) {
+		case Field:
+			fields = append(fields, v)
+		case Fields:
+			fields = append(fields, v...)
+		default:
+			key, ok := v.(string)
+			if !ok {
+				key = fmt.Sprint(v)
+			}
+			var value interface{}
+			if i+1 < len(kv) {
+				value = kv[i+1]
+				i++
+			}
+			fields = append(fields, Field{Key: key, Value: value})
+		}
+	}
+	return fields
+}
+
+// merge returns a new list with the fields from both lists, fields in more replace fields in
+// fs with the same key. Neither list is modified.
+func (fs Fields) merge(more Fields) Fields {
+	merged := make(Fields, len(fs), len(fs)+len(more))
+	copy(merged, fs)
+
+	for _, f := range more {
+		replaced := false
+		for i := range merged {
+			if merged[i].Key == f.Key {
+				merged[i] = f
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, f)
+		}
+	}
+
+	return merged
+}
+
+// Format writes the fields as " key=value" pairs, so that a formatter rendering its format string with
+// fmt.Sprintf renders any fields appended to its arguments.
+func (fs Fields) Format(s fmt.State, verb rune) {
+	for _, f := range fs {
+		s.Write([]byte(" "))
+		s.Write([]byte(f.Key))
+		s.Write([]byte("="))
+		s.Write([]byte(quoteValue(fieldValueString(f.Value))))
+	}
+}
+
+// String returns the fields as space separated key=value pairs
+func (fs Fields) String() string {
+	return strings.TrimPrefix(fmt.Sprint(fs), " ")
+}
+
+// fieldValueString converts a field value to a string, errors use their message
+func fieldValueString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "<nil>"
+	case string:
+		return v
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// quoteValue quotes a value if it is empty or contains spaces, quotes, an equals sign, control characters
+// or invalid UTF-8
+func quoteValue(value string) string {
+	if value == "" {
+		return `""`
+	}
+
+	for _, r := range value {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || r == 0x7f {
+			return strconv.Quote(value)
+		}
+	}
+
+	return value
+}
@@ -0,0 +1,80 @@
+package lg
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFieldHelpers(t *testing.T) {
+	err := errors.New("bad thing")
+	fields := Fields{
+		Any("any", []int{1, 2}),
+		String("string", "value"),
+		Int("int", 1),
+		Int64("int64", 2),
+		Float64("float", 1.5),
+		Bool("bool", true),
+		Duration("duration", time.Second),
+		Err(err),
+	}
+
+	require.Equal(t, "any=\"[1 2]\" string=value int=1 int64=2 float=1.5 bool=true duration=1s error=\"bad thing\"", fields.String())
+}
+
+func TestToFields(t *testing.T) {
+	fields := toFields([]interface{}{"one", 1, String("two", "2"), Fields{Int("three", 3)}, 4, "four", "dangling"})
+	require.Equal(t, Fields{
+		Int("one", 1),
+		String("two", "2"),
+		Int("three", 3),
+		Any("4", "four"),
+		Any("dangling", nil),
+	}, fields)
+}
+
+func TestFieldQuoting(t *testing.T) {
+	require.Equal(t, `""`, quoteValue(""))
+	require.Equal(t, "plain", quoteValue("plain"))
+	require.Equal(t, `"with space"`, quoteValue("with space"))
+	require.Equal(t, `"a=b"`, quoteValue("a=b"))
+	require.Equal(t, `"say \"hi\""`, quoteValue(`say "hi"`))
+	require.Equal(t, `"line\nbreak"`, quoteValue("line\nbreak"))
+	require.Equal(t, `"\xff"`, quoteValue("\xff"))
+}
+
+func TestFieldsWithPercent(t *testing.T) {
+	entry := &Entry{Format: "%d%% done", Args: []interface{}{50}, Fields: Fields{String("pct", "100%")}}
+	require.Equal(t, "50% done pct=100%", entry.FormatWith(MinimalFormat))
+}
+
+func TestFieldsWithMismatchedVerbs(t *testing.T) {
+	now := time.Date(2024, 1, 2, 15, 4, 5, 0, time.Local)
+	a := &ArrayAppender{}
+	logger := NewLoggerWithConfig(MinimalFormat, a.Log)
+	logger.SetClock(func() time.Time { return now })
+	child := logger.With("k", "v")
+
+	child.Printf("100%")
+	child.Printf("%s %s", "a")
+	child.Printf("%s", "a", "b")
+	require.Equal(t, []string{
+		"100%!(NOVERB) k=v",
+		"a %!s(MISSING) k=v",
+		"a%!(EXTRA string=b) k=v",
+	}, a.Entries)
+
+	stamp := DefaultTimeFormat(now)
+	for _, formatter := range []LogFormatter{SimpleFormat, FullFormat} {
+		a.Entries = nil
+		logger.Configure(formatter, a.Log)
+		child.Printf("100%")
+		child.Printf("%s %s", "a")
+		require.Equal(t, []string{
+			stamp + " [INF] 100%!(NOVERB) k=v",
+			stamp + " [INF] a %!s(MISSING) k=v",
+		}, a.Entries)
+	}
+}
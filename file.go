@@ -0,0 +1,79 @@
+package lg
+
+import (
+	"os"
+	"sync"
+)
+
+// FileAppender appends entries to a single file, followed by a new line. The file is opened in append
+// mode and created if it doesn't exist.
+type FileAppender struct {
+	sync.Mutex
+	path string
+	file *os.File
+}
+
+// NewFileAppender opens the file at path and returns an appender for it
+func NewFileAppender(path string) (*FileAppender, error) {
+	appender := &FileAppender{
+		path: path,
+	}
+
+	err := appender.Reopen()
+	if err != nil {
+		return nil, err
+	}
+
+	return appender, nil
+}
+
+// Path returns the path of the file the appender writes to
+func (appender *FileAppender) Path() string {
+	return appender.path
+}
+
+// Log is FileAppender's implementation of LogAppender
+func (appender *FileAppender) Log(entry string) error {
+	appender.Lock()
+	defer appender.Unlock()
+
+	if appender.file == nil {
+		return os.ErrClosed
+	}
+
+	_, err := appender.file.WriteString(entry + "\n")
+	return err
+}
+
+// Reopen closes the file, if it is open, and opens it again. This can be used after the file
+// is moved by an external tool like logrotate.
+func (appender *FileAppender) Reopen() error {
+	appender.Lock()
+	defer appender.Unlock()
+
+	f, err := os.OpenFile(appender.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	if appender.file != nil {
+		appender.file.Close()
+	}
+	appender.file = f
+
+	return nil
+}
+
+// Close closes the file, entries logged after Close will return an error
+func (appender *FileAppender) Close() error {
+	appender.Lock()
+	defer appender.Unlock()
+
+	if appender.file == nil {
+		return nil
+	}
+
+	err := appender.file.Close()
+	appender.file = nil
+	return err
+}
@@ -0,0 +1,47 @@
+package lg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileAppender(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lgfile")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "out.log")
+
+	appender, err := NewFileAppender(path)
+	require.NoError(t, err)
+	require.Equal(t, path, appender.Path())
+
+	logger := NewLoggerWithConfig(MinimalFormat, appender.Log)
+	logger.Printf("one")
+
+	// simulate logrotate moving the file
+	rotated := path + ".1"
+	require.NoError(t, os.Rename(path, rotated))
+	logger.Printf("two")
+	require.NoError(t, appender.Reopen())
+	logger.Printf("three")
+	require.NoError(t, appender.Close())
+	require.Error(t, logger.Printf("four"))
+	require.NoError(t, appender.Close())
+
+	content, err := ioutil.ReadFile(rotated)
+	require.NoError(t, err)
+	require.Equal(t, "one\ntwo\n", string(content))
+
+	content, err = ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "three\n", string(content))
+}
+
+func TestFileAppenderBadPath(t *testing.T) {
+	_, err := NewFileAppender("/does/not/exist/out.log")
+	require.Error(t, err)
+}
@@ -1,8 +1,11 @@
 module github.com/sasbury/lg
 
-go 1.14
+go 1.19
+
+require github.com/stretchr/testify v1.6.1
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/stretchr/testify v1.6.1
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
 )
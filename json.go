@@ -0,0 +1,500 @@
+package lg
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// JSONOptions configures the keys used by NewJSONFormatter and NewJSONParser, empty keys use the defaults
+type JSONOptions struct {
+	TimeKey    string // defaults to "time"
+	LevelKey   string // defaults to "level"
+	TagsKey    string // defaults to "tags"
+	MessageKey string // defaults to "msg"
+	FieldsKey  string // defaults to "fields"
+
+	// Time formats the time, defaults to time.RFC3339Nano. The parser reads RFC3339 and EpochMillis times,
+	// other times are left as the zero time.
+	Time TimeFormatter
+
+	// InlineFields writes fields as top level keys rather than in an object under FieldsKey. Fields with the
+	// same key as one of the entry's own keys are written with FieldsKey and a dot as a prefix, like "fields.msg".
+	InlineFields bool
+}
+
+// The keys used for the caller and stack, these aren't configurable
+const (
+	jsonCallerKey     = "caller"
+	jsonStackKey      = "stack"
+	jsonGoroutinesKey = "goroutines"
+)
+
+// The levels written by the JSON formatter
+const (
+	jsonInfoLevel  = "info"
+	jsonDebugLevel = "debug"
+)
+
+// withDefaults returns a copy of the options with the empty keys set to the defaults
+func (o JSONOptions) withDefaults() JSONOptions {
+	if o.TimeKey == "" {
+		o.TimeKey = "time"
+	}
+	if o.LevelKey == "" {
+		o.LevelKey = "level"
+	}
+	if o.TagsKey == "" {
+		o.TagsKey = "tags"
+	}
+	if o.MessageKey == "" {
+		o.MessageKey = "msg"
+	}
+	if o.FieldsKey == "" {
+		o.FieldsKey = "fields"
+	}
+	return o
+}
+
+// isReserved returns true if the key is used for something other than a field
+func (o JSONOptions) isReserved(key string) bool {
+	switch key {
+	case o.TimeKey, o.LevelKey, o.TagsKey, o.MessageKey, o.FieldsKey, jsonCallerKey, jsonStackKey, jsonGoroutinesKey:
+		return true
+	}
+	return false
+}
+
+// JSONFormat writes each entry as a JSON object on one line, using the default JSONOptions:
+//
+//	{"time":"2024-01-02T15:04:05.123456789Z","level":"info","tags":["db"],"msg":"connected","fields":{"host":"db1"}}
+//
+// The time is formatted with time.RFC3339Nano and the level is "info" or "debug". The fields object is left out
+// when there are no fields. If caller capture is on the caller is added as "caller", stacks are added as
+// "stack", an array of objects with a function, file and line, and goroutine dumps as "goroutines".
+// Strings are escaped so that control characters and invalid UTF-8 can't break the line.
+var JSONFormat = NewJSONFormatter(JSONOptions{})
+
+// NewJSONFormatter returns a formatter that writes each entry as a JSON object on one line, see JSONFormat
+func NewJSONFormatter(opts JSONOptions) LogFormatter {
+	opts = opts.withDefaults()
+	timeFormat := opts.Time
+	if timeFormat == nil {
+		timeFormat = rfc3339Nano
+	}
+
+	return func(debug bool, tags []string, t time.Time, format string, args ...interface{}) string {
+		format, args, entry := SplitEntry(format, args)
+
+		buf := &bytes.Buffer{}
+		buf.WriteByte('{')
+
+		writeJSONKey(buf, opts.TimeKey, true)
+		writeJSONString(buf, timeFormat(t))
+
+		writeJSONKey(buf, opts.LevelKey, false)
+		if debug {
+			writeJSONString(buf, jsonDebugLevel)
+		} else {
+			writeJSONString(buf, jsonInfoLevel)
+		}
+
+		writeJSONKey(buf, opts.TagsKey, false)
+		buf.WriteByte('[')
+		for i, tag := range tags {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeJSONString(buf, tag)
+		}
+		buf.WriteByte(']')
+
+		writeJSONKey(buf, opts.MessageKey, false)
+		writeJSONString(buf, fmt.Sprintf(format, args...))
+
+		if entry == nil {
+			buf.WriteByte('}')
+			return buf.String()
+		}
+
+		if entry.Caller != nil {
+			writeJSONKey(buf, jsonCallerKey, false)
+			writeJSONString(buf, entry.Caller.String())
+		}
+
+		if len(entry.Stack) > 0 {
+			writeJSONKey(buf, jsonStackKey, false)
+			buf.WriteByte('[')
+			for i, frame := range entry.Stack {
+				if i > 0 {
+					buf.WriteByte(',')
+				}
+				buf.WriteByte('{')
+				writeJSONKey(buf, "function", true)
+				writeJSONString(buf, frame.Function)
+				writeJSONKey(buf, "file", false)
+				writeJSONString(buf, frame.File)
+				writeJSONKey(buf, "line", false)
+				buf.WriteString(strconv.Itoa(frame.Line))
+				buf.WriteByte('}')
+			}
+			buf.WriteByte(']')
+		}
+
+		if entry.Goroutines != "" {
+			writeJSONKey(buf, jsonGoroutinesKey, false)
+			writeJSONString(buf, entry.Goroutines)
+		}
+
+		if len(entry.Fields) > 0 {
+			if opts.InlineFields {
+				for _, f := range entry.Fields {
+					key := f.Key
+					if opts.isReserved(key) {
+						key = opts.FieldsKey + "." + key
+					}
+					writeJSONKey(buf, key, false)
+					writeJSONValue(buf, f.Value)
+				}
+			} else {
+				writeJSONKey(buf, opts.FieldsKey, false)
+				buf.WriteByte('{')
+				for i, f := range entry.Fields {
+					writeJSONKey(buf, f.Key, i == 0)
+					writeJSONValue(buf, f.Value)
+				}
+				buf.WriteByte('}')
+			}
+		}
+
+		buf.WriteByte('}')
+		return buf.String()
+	}
+}
+
+// writeJSONKey writes a key and colon, preceded by a comma unless it is the first key
+func writeJSONKey(buf *bytes.Buffer, key string, first bool) {
+	if !first {
+		buf.WriteByte(',')
+	}
+	writeJSONString(buf, key)
+	buf.WriteByte(':')
+}
+
+const hexDigits = "0123456789abcdef"
+
+// writeJSONString writes a quoted JSON string, control characters are escaped and invalid UTF-8 is replaced with U+FFFD
+func writeJSONString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			switch {
+			case c == '"' || c == '\\':
+				buf.WriteByte('\\')
+				buf.WriteByte(c)
+			case c == '\n':
+				buf.WriteString(`\n`)
+			case c == '\r':
+				buf.WriteString(`\r`)
+			case c == '\t':
+				buf.WriteString(`\t`)
+			case c < 0x20 || c == 0x7f:
+				buf.WriteString(`\u00`)
+				buf.WriteByte(hexDigits[c>>4])
+				buf.WriteByte(hexDigits[c&0xf])
+			default:
+				buf.WriteByte(c)
+			}
+			i++
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			buf.WriteString(`\ufffd`)
+		case r == '\u2028' || r == '\u2029':
+			// valid JSON, but these end lines in javascript
+			buf.WriteString(`\u202`)
+			buf.WriteByte(hexDigits[r&0xf])
+		default:
+			buf.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	buf.WriteByte('"')
+}
+
+// writeJSONValue writes a field value, numbers and bools are written as JSON numbers and bools, errors and
+// fmt.Stringers as their strings, values that implement json.Marshaler, or that can be marshaled, as JSON,
+// and anything else as the string used by the other formatters
+func writeJSONValue(buf *bytes.Buffer, value interface{}) {
+	switch v := value.(type) {
+	case nil:
+		buf.WriteString("null")
+	case string:
+		writeJSONString(buf, v)
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case int:
+		buf.WriteString(strconv.FormatInt(int64(v), 10))
+	case int8:
+		buf.WriteString(strconv.FormatInt(int64(v), 10))
+	case int16:
+		buf.WriteString(strconv.FormatInt(int64(v), 10))
+	case int32:
+		buf.WriteString(strconv.FormatInt(int64(v), 10))
+	case int64:
+		buf.WriteString(strconv.FormatInt(v, 10))
+	case uint:
+		buf.WriteString(strconv.FormatUint(uint64(v), 10))
+	case uint8:
+		buf.WriteString(strconv.FormatUint(uint64(v), 10))
+	case uint16:
+		buf.WriteString(strconv.FormatUint(uint64(v), 10))
+	case uint32:
+		buf.WriteString(strconv.FormatUint(uint64(v), 10))
+	case uint64:
+		buf.WriteString(strconv.FormatUint(v, 10))
+	case float32:
+		writeJSONFloat(buf, float64(v), 32)
+	case float64:
+		writeJSONFloat(buf, v, 64)
+	case json.Number:
+		if _, err := strconv.ParseFloat(v.String(), 64); err != nil {
+			writeJSONString(buf, v.String())
+			return
+		}
+		buf.WriteString(v.String())
+	case error:
+		writeJSONString(buf, v.Error())
+	case json.Marshaler:
+		writeJSONMarshaled(buf, v)
+	case fmt.Stringer:
+		writeJSONString(buf, v.String())
+	default:
+		writeJSONMarshaled(buf, v)
+	}
+}
+
+// writeJSONFloat writes a float as a number, NaN and infinities can't be JSON numbers and are written as strings
+func writeJSONFloat(buf *bytes.Buffer, f float64, bits int) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		writeJSONString(buf, strconv.FormatFloat(f, 'g', -1, bits))
+		return
+	}
+	buf.WriteString(strconv.FormatFloat(f, 'g', -1, bits))
+}
+
+// writeJSONMarshaled writes the value with json.Marshal, or as a string if it can't be marshaled
+func writeJSONMarshaled(buf *bytes.Buffer, value interface{}) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		writeJSONString(buf, fieldValueString(value))
+		return
+	}
+	buf.Write(data)
+}
+
+// ParseJSON parses a line written by JSONFormat, see NewJSONParser
+func ParseJSON(data []byte) (*Entry, error) {
+	return parseJSON(data, JSONOptions{}.withDefaults())
+}
+
+// NewJSONParser returns a function that parses lines written by a formatter from NewJSONFormatter with the same
+// options. The entry's Format is "%s" with the message as its only argument, so Message returns the message.
+// Fields keep their order, numbers are json.Number values and objects and arrays are decoded with encoding/json.
+// The caller's file is the directory and file written by the formatter. With InlineFields, any key that isn't one
+// of the entry's own keys is a field, and keys with the fields prefix have it removed.
+func NewJSONParser(opts JSONOptions) func(data []byte) (*Entry, error) {
+	opts = opts.withDefaults()
+	return func(data []byte) (*Entry, error) {
+		return parseJSON(data, opts)
+	}
+}
+
+// parseJSON parses a line written with the options, which already have their defaults
+func parseJSON(data []byte, opts JSONOptions) (*Entry, error) {
+	var raw []jsonMember
+	err := decodeJSONObject(data, &raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON log entry: %v", err)
+	}
+
+	entry := &Entry{
+		Format: "%s",
+		Args:   []interface{}{""},
+	}
+
+	fieldsPrefix := opts.FieldsKey + "."
+
+	for _, m := range raw {
+		switch m.key {
+		case opts.TimeKey:
+			var s string
+			err = json.Unmarshal(m.value, &s)
+			if err == nil {
+				entry.Time, err = parseJSONTime(s, opts.Time == nil)
+			}
+		case opts.LevelKey:
+			var s string
+			err = json.Unmarshal(m.value, &s)
+			if err == nil {
+				switch s {
+				case jsonDebugLevel:
+					entry.Debug = true
+				case jsonInfoLevel:
+				default:
+					err = fmt.Errorf("unknown level %q", s)
+				}
+			}
+		case opts.TagsKey:
+			err = json.Unmarshal(m.value, &entry.Tags)
+			if err == nil && len(entry.Tags) == 0 {
+				entry.Tags = nil
+			}
+		case opts.MessageKey:
+			var s string
+			err = json.Unmarshal(m.value, &s)
+			entry.Args[0] = s
+		case jsonCallerKey:
+			var s string
+			err = json.Unmarshal(m.value, &s)
+			if err == nil {
+				entry.Caller, err = parseCaller(s)
+			}
+		case jsonStackKey:
+			var frames []struct {
+				Function string `json:"function"`
+				File     string `json:"file"`
+				Line     int    `json:"line"`
+			}
+			err = json.Unmarshal(m.value, &frames)
+			for _, f := range frames {
+				entry.Stack = append(entry.Stack, Caller{Function: f.Function, File: f.File, Line: f.Line})
+			}
+		case jsonGoroutinesKey:
+			err = json.Unmarshal(m.value, &entry.Goroutines)
+		case opts.FieldsKey:
+			if opts.InlineFields {
+				entry.Fields, err = appendJSONField(entry.Fields, m.key, m.value)
+				break
+			}
+			var fields []jsonMember
+			err = decodeJSONObject(m.value, &fields)
+			for _, f := range fields {
+				if err != nil {
+					break
+				}
+				entry.Fields, err = appendJSONField(entry.Fields, f.key, f.value)
+			}
+		default:
+			if !opts.InlineFields {
+				break
+			}
+			key := m.key
+			if strings.HasPrefix(key, fieldsPrefix) && opts.isReserved(key[len(fieldsPrefix):]) {
+				key = key[len(fieldsPrefix):]
+			}
+			entry.Fields, err = appendJSONField(entry.Fields, key, m.value)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON log entry: %s: %v", m.key, err)
+		}
+	}
+
+	return entry, nil
+}
+
+// parseJSONTime parses an RFC3339 time, or a time from EpochMillis. If strict is false, times in other
+// formats are returned as the zero time rather than an error.
+func parseJSONTime(s string, strict bool) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err == nil || strict {
+		return t, err
+	}
+
+	millis, err := strconv.ParseInt(s, 10, 64)
+	if err == nil {
+		return time.Unix(0, millis*int64(time.Millisecond)), nil
+	}
+
+	return time.Time{}, nil
+}
+
+// jsonMember is a key and its undecoded value, used to keep the order of an object's keys
+type jsonMember struct {
+	key   string
+	value json.RawMessage
+}
+
+// decodeJSONObject decodes an object into its members, in order
+func decodeJSONObject(data []byte, members *[]jsonMember) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '{' {
+		return errors.New("expected an object")
+	}
+
+	for decoder.More() {
+		token, err = decoder.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := token.(string)
+
+		var value json.RawMessage
+		err = decoder.Decode(&value)
+		if err != nil {
+			return err
+		}
+		*members = append(*members, jsonMember{key: key, value: value})
+	}
+
+	_, err = decoder.Token()
+	if err != nil {
+		return err
+	}
+	if decoder.More() {
+		return errors.New("unexpected data after the object")
+	}
+	return nil
+}
+
+// appendJSONField decodes the value and adds the field, numbers are decoded as json.Number
+func appendJSONField(fields Fields, key string, data json.RawMessage) (Fields, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	err := decoder.Decode(&value)
+	if err != nil {
+		return fields, err
+	}
+	return append(fields, Field{Key: key, Value: value}), nil
+}
+
+// parseCaller parses a caller written as file:line
+func parseCaller(s string) (*Caller, error) {
+	i := strings.LastIndexByte(s, ':')
+	if i < 0 {
+		return nil, fmt.Errorf("invalid caller %q", s)
+	}
+	line, err := strconv.Atoi(s[i+1:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid caller %q", s)
+	}
+	return &Caller{File: s[:i], Line: line}, nil
+}
@@ -0,0 +1,149 @@
+package lg
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONFormat(t *testing.T) {
+	now := time.Date(2024, 1, 2, 15, 4, 5, 123456789, time.UTC)
+
+	require.Equal(t, `{"time":"2024-01-02T15:04:05.123456789Z","level":"info","tags":[],"msg":"hello world"}`,
+		JSONFormat(false, nil, now, "hello %s", "world"))
+	require.Equal(t, `{"time":"2024-01-02T15:04:05.123456789Z","level":"debug","tags":["red","blue"],"msg":"hello"}`,
+		JSONFormat(true, []string{"red", "blue"}, now, "hello"))
+
+	entry := &Entry{
+		Time:   now,
+		Format: "connected to %s",
+		Args:   []interface{}{"db1"},
+		Fields: Fields{String("host", "db1"), Int("port", 5432), Err(errors.New("oops")), Duration("took", time.Second)},
+		Caller: &Caller{File: "/src/server/main.go", Line: 12},
+	}
+	require.Equal(t, `{"time":"2024-01-02T15:04:05.123456789Z","level":"info","tags":[],"msg":"connected to db1",`+
+		`"caller":"server/main.go:12","fields":{"host":"db1","port":5432,"error":"oops","took":"1s"}}`,
+		entry.FormatWith(JSONFormat))
+
+	formatter, err := FormatterByName("json")
+	require.NoError(t, err)
+	require.Equal(t, entry.FormatWith(JSONFormat), entry.FormatWith(formatter))
+}
+
+func TestJSONEscaping(t *testing.T) {
+	line := JSONFormat(false, []string{"a\"b"}, time.Now(), "%s", "line\none\ttab \x01 \x7f \xff \u2028 <html> \u00e9")
+	require.True(t, json.Valid([]byte(line)), line)
+	require.NotContains(t, line, "\n")
+	require.Contains(t, line, `line\none\ttab \u0001 \u007f \ufffd \u2028 <html> `+"\u00e9")
+	require.Contains(t, line, `["a\"b"]`)
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(line), &decoded))
+	require.Equal(t, "line\none\ttab \x01 \x7f \ufffd \u2028 <html> \u00e9", decoded["msg"])
+}
+
+func TestJSONValues(t *testing.T) {
+	entry := &Entry{
+		Format: "values",
+		Fields: Fields{
+			Any("nil", nil),
+			Bool("bool", true),
+			Float64("float", 1.5),
+			Float64("nan", math.NaN()),
+			Any("uint", uint8(7)),
+			Any("slice", []int{1, 2}),
+			Any("map", map[string]int{"a": 1}),
+			Any("func", func() {}),
+			Any("number", json.Number("12.5")),
+			Any("badnumber", json.Number("12x")),
+		},
+	}
+
+	line := entry.FormatWith(JSONFormat)
+	require.True(t, json.Valid([]byte(line)), line)
+	require.Contains(t, line, `"fields":{"nil":null,"bool":true,"float":1.5,"nan":"NaN","uint":7,"slice":[1,2],"map":{"a":1},"func":"0x`)
+	require.Contains(t, line, `"number":12.5,"badnumber":"12x"}`)
+}
+
+func TestJSONInlineFields(t *testing.T) {
+	formatter := NewJSONFormatter(JSONOptions{MessageKey: "message", InlineFields: true})
+	entry := &Entry{
+		Time:   time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC),
+		Format: "hello",
+		Fields: Fields{String("user", "bob"), String("message", "clash"), String("fields.other", "kept")},
+	}
+
+	line := entry.FormatWith(formatter)
+	require.Equal(t, `{"time":"2024-01-02T15:04:05Z","level":"info","tags":[],"message":"hello",`+
+		`"user":"bob","fields.message":"clash","fields.other":"kept"}`, line)
+
+	parsed, err := NewJSONParser(JSONOptions{MessageKey: "message", InlineFields: true})([]byte(line))
+	require.NoError(t, err)
+	require.Equal(t, "hello", parsed.Message())
+	require.Equal(t, Fields{String("user", "bob"), String("message", "clash"), String("fields.other", "kept")}, parsed.Fields)
+}
+
+func TestParseJSON(t *testing.T) {
+	entry := &Entry{
+		Time:   time.Date(2024, 1, 2, 15, 4, 5, 123456789, time.UTC),
+		Debug:  true,
+		Tags:   []string{"db", "slow"},
+		Format: "query %q took %v",
+		Args:   []interface{}{"select 1", time.Second},
+		Fields: Fields{String("host", "db1"), Int("port", 5432), Bool("ok", false), Any("nil", nil), Any("list", []string{"a"})},
+		Caller: &Caller{File: "/src/server/main.go", Line: 12},
+		Stack: []Caller{
+			{Function: "main.query", File: "/src/server/main.go", Line: 12},
+			{Function: "main.main", File: "/src/server/main.go", Line: 40},
+		},
+	}
+
+	line := entry.FormatWith(JSONFormat)
+	parsed, err := ParseJSON([]byte(line))
+	require.NoError(t, err)
+
+	require.True(t, parsed.Time.Equal(entry.Time))
+	require.True(t, parsed.Debug)
+	require.Equal(t, entry.Tags, parsed.Tags)
+	require.Equal(t, entry.Message(), parsed.Message())
+	require.Equal(t, &Caller{File: "server/main.go", Line: 12}, parsed.Caller)
+	require.Equal(t, entry.Stack, parsed.Stack)
+	require.Equal(t, Fields{
+		String("host", "db1"),
+		Any("port", json.Number("5432")),
+		Bool("ok", false),
+		Any("nil", nil),
+		Any("list", []interface{}{"a"}),
+	}, parsed.Fields)
+
+	// formatting the parsed entry gives the same line
+	require.Equal(t, line, parsed.FormatWith(JSONFormat))
+
+	parsed, err = ParseJSON([]byte(JSONFormat(false, nil, entry.Time, "100%% done")))
+	require.NoError(t, err)
+	require.Equal(t, "100% done", parsed.Message())
+	require.False(t, parsed.Debug)
+	require.Nil(t, parsed.Tags)
+	require.Nil(t, parsed.Fields)
+}
+
+func TestParseJSONErrors(t *testing.T) {
+	for _, line := range []string{
+		``,
+		`[]`,
+		`{"msg":"x"`,
+		`{"msg":"x"} {}`,
+		`{"time":"yesterday"}`,
+		`{"level":"warn"}`,
+		`{"tags":"red"}`,
+		`{"caller":"main.go"}`,
+		`{"fields":[1]}`,
+	} {
+		_, err := ParseJSON([]byte(line))
+		require.Error(t, err, line)
+	}
+}
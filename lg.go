@@ -5,21 +5,118 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // Logger provides a minimal configuration and methods to print and debug, with or without tags.
 // Debugging can be configured at the tag level or for the entire logger.
+// Loggers created with With or WithTags share their configuration, and lock, with the logger they came from.
+// The zero value is a logger with no appender and debug mode off, its configuration is created the first time it is used.
 type Logger struct {
+	shared     atomic.Pointer[config]
+	fields     Fields
+	tags       []string
+	forceDebug bool
+}
+
+// config holds the state shared by a logger and its children. The appender is protected by the lock,
+// the debug state is an immutable snapshot that is replaced inside the lock but can be read without it.
+// The suppressed debug appender is also read without the lock, so debug calls that don't print stay cheap.
+type config struct {
 	sync.RWMutex
-	debug     bool
-	debugTags []string
-	format    LogFormatter
-	appender  LogAppender
+	debug       atomic.Pointer[debugState]
+	suppressed  atomic.Pointer[EntryAppender]
+	appender    EntryAppender
+	formatter   LogFormatter // the pair passed to Configure, nil if an EntryAppender was configured
+	logAppender LogAppender
+	envFile     *FileAppender // the file opened for the OUTPUT environment variable, closed when the appender is replaced
+
+	captureCaller bool
+	callerSkip    int
+
+	clock func() time.Time
+}
+
+// newConfig creates a config with debug mode off and no appender
+func newConfig() *config {
+	c := &config{}
+	c.debug.Store(&debugState{})
+	return c
+}
+
+// config returns the configuration shared with the logger's children, a zero value Logger gets a new one the
+// first time it is used
+func (l *Logger) config() *config {
+	if c := l.shared.Load(); c != nil {
+		return c
+	}
+	l.shared.CompareAndSwap(nil, newConfig())
+	return l.shared.Load()
+}
+
+// child returns a logger that shares the configuration with l
+func (l *Logger) child(fields Fields, tags []string, forceDebug bool) *Logger {
+	c := &Logger{
+		fields:     fields,
+		tags:       tags,
+		forceDebug: forceDebug,
+	}
+	c.shared.Store(l.config())
+	return c
+}
+
+// Lock locks the configuration shared by the logger and its children for writing
+func (l *Logger) Lock() {
+	l.config().Lock()
+}
+
+// Unlock unlocks the configuration shared by the logger and its children for writing
+func (l *Logger) Unlock() {
+	l.config().Unlock()
+}
+
+// RLock locks the configuration shared by the logger and its children for reading
+func (l *Logger) RLock() {
+	l.config().RLock()
+}
+
+// RUnlock unlocks the configuration shared by the logger and its children for reading
+func (l *Logger) RUnlock() {
+	l.config().RUnlock()
+}
+
+// emptyDebugState is the debug state of a config that hasn't published one
+var emptyDebugState = &debugState{}
+
+// loadDebug returns the current debug state, a config that hasn't published one has debug mode off
+func (c *config) loadDebug() *debugState {
+	if s := c.debug.Load(); s != nil {
+		return s
+	}
+	return emptyDebugState
+}
+
+// updateDebug publishes a copy of the debug state with the change applied
+func (c *config) updateDebug(change func(s *debugState)) {
+	c.Lock()
+	c.changeDebug(change)
+	c.Unlock()
+}
+
+// changeDebug publishes a copy of the debug state with the change applied, the lock is held
+func (c *config) changeDebug(change func(s *debugState)) {
+	s := *c.loadDebug()
+	change(&s)
+	c.debug.Store(&s)
 }
 
 // LogFormatter is used to convert a logmessage to a string for printing
-// The logger's lock will be used to protect the formatter
+// The logger will protect the formatter with a lock, so calls to the formatter passed to Configure are serialized
+// When an entry has fields, a caller or a stack, a %v verb is appended to fmt and the entry is appended to args,
+// so formatters that use fmt.Sprintf render the fields, and stack, after the message. Formatters that want to
+// render them, or the caller, themselves can remove the entry with SplitEntry. The built-in formatters all do, so a
+// format whose verbs don't match its args doesn't garble the fields.
 type LogFormatter func(debug bool, tags []string, t time.Time, fmt string, args ...interface{}) string
 
 // LogAppender is used to write the log message to a destination
@@ -29,22 +126,126 @@ type LogAppender func(entry string) error
 
 // NewLogger creates and returns a new default logger
 func NewLogger() *Logger {
-	return &Logger{
-		format:    SimpleFormat,
-		appender:  StdErrAppender,
-		debug:     false,
-		debugTags: []string{},
-	}
+	return NewLoggerWithConfig(SimpleFormat, StdErrAppender)
 }
 
 // NewLoggerWithConfig creates and returns a new logger with the specified format and appender
 func NewLoggerWithConfig(formatter LogFormatter, appender LogAppender) *Logger {
-	return &Logger{
-		format:    formatter,
-		appender:  appender,
-		debug:     false,
-		debugTags: []string{},
+	l := &Logger{}
+	l.Configure(formatter, appender)
+	return l
+}
+
+// NewLoggerWithEntryAppender creates and returns a new logger that passes entries to the appender
+func NewLoggerWithEntryAppender(appender EntryAppender) *Logger {
+	l := &Logger{}
+	l.ConfigureEntryAppender(appender)
+	return l
+}
+
+// With returns a child logger that adds fields to every entry it prints. The arguments can be
+// Field values, like lg.String("user", name), or alternating keys and values. The child shares the
+// debug state, formatter and appender of its parent, so changes to either are seen by both.
+// Fields on the child replace parent fields with the same key.
+// A nil logger returns nil.
+func (l *Logger) With(kv ...interface{}) *Logger {
+	if l == nil {
+		return nil
 	}
+	return l.child(l.fields.merge(toFields(kv)), l.tags, l.forceDebug)
+}
+
+// WithTags returns a child logger that adds tags to every entry it prints, Printf and Debugf on the
+// child behave like TagPrintf and TagDebugf with those tags. Tags passed to TagPrintf or TagDebugf
+// on the child are added after the child's tags. The child shares the debug state, formatter and
+// appender of its parent, so EnableDebugModeFor can be used to turn on debug for everything a
+// component logs.
+// A nil logger returns nil.
+func (l *Logger) WithTags(tags ...string) *Logger {
+	if l == nil {
+		return nil
+	}
+	return l.child(l.fields, mergeTags(l.tags, tags), l.forceDebug)
+}
+
+// WithForceDebug returns a child logger that prints debug entries regardless of the debug state, including
+// exclusions. It is meant for following a single request, the child shares everything else with its parent.
+// A nil logger returns nil.
+func (l *Logger) WithForceDebug() *Logger {
+	if l == nil {
+		return nil
+	}
+	return l.child(l.fields, l.tags, true)
+}
+
+// IsForceDebug returns true if the logger prints debug entries regardless of the debug state
+func (l *Logger) IsForceDebug() bool {
+	return l != nil && l.forceDebug
+}
+
+// Tags returns a copy of the tags attached to the logger
+func (l *Logger) Tags() []string {
+	if l == nil {
+		return nil
+	}
+	return append([]string{}, l.tags...)
+}
+
+// mergeTags returns the tags in first followed by any tags in second that aren't in first.
+// If either list is empty the other is returned without copying.
+func mergeTags(first []string, second []string) []string {
+	if len(first) == 0 {
+		return second
+	}
+	if len(second) == 0 {
+		return first
+	}
+
+	merged := make([]string, len(first), len(first)+len(second))
+	copy(merged, first)
+
+	for _, t := range second {
+		found := false
+		for _, m := range first {
+			if m == t {
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, t)
+		}
+	}
+
+	return merged
+}
+
+// Fields returns a copy of the fields attached to the logger
+func (l *Logger) Fields() Fields {
+	if l == nil {
+		return nil
+	}
+	return append(Fields{}, l.fields...)
+}
+
+// newEntry creates an entry with the logger's fields at the time now
+func (l *Logger) newEntry(now time.Time, debug bool, tags []string, format string, args []interface{}) *Entry {
+	return &Entry{
+		Time:   now,
+		Debug:  debug,
+		Tags:   tags,
+		Format: format,
+		Args:   args,
+		Fields: l.fields,
+	}
+}
+
+// newAppender adapts a formatter and appender, a nil appender results in a nil EntryAppender
+func newAppender(formatter LogFormatter, appender LogAppender) EntryAppender {
+	if appender == nil {
+		return nil
+	}
+	return NewFormattingAppender(formatter, appender)
 }
 
 // Write implements the Writer interface, so that a logger can be used to adapt
@@ -53,216 +254,430 @@ func NewLoggerWithConfig(formatter LogFormatter, appender LogAppender) *Logger {
 // This is equivalent to calling "Printf"
 func (l *Logger) Write(p []byte) (n int, err error) {
 	s := string(p[:])
-	l.Printf(s)
+	if l != nil {
+		l.output(1, false, noStack, l.tags, s, nil)
+	}
 	return len(p), nil
 }
 
 // EnableDebugMode turns on debug mode for all tags
 func (l *Logger) EnableDebugMode() {
-	l.Lock()
-	l.debug = true
-	l.Unlock()
+	l.config().updateDebug(func(s *debugState) {
+		s.debug = true
+	})
 }
 
 // DisableDebugMode turns off the debug flag, individual tags may still have debug mode on
 func (l *Logger) DisableDebugMode() {
-	l.Lock()
-	l.debug = false
-	l.Unlock()
+	l.config().updateDebug(func(s *debugState) {
+		s.debug = false
+	})
 }
 
-// DisableDebugModeAll turns off the debug flag, and removes any debug flags
+// DisableDebugModeAll turns off the debug flag, and removes any debug flags and exclusions
 func (l *Logger) DisableDebugModeAll() {
-	l.Lock()
-	l.debug = false
-	l.debugTags = []string{}
-	l.Unlock()
+	l.config().updateDebug(func(s *debugState) {
+		*s = debugState{}
+	})
+}
+
+// SetDebugMode replaces the debug flag, debug tags and exclusions in a single update, so that no
+// debug call sees a partial change
+func (l *Logger) SetDebugMode(debug bool, tags []string, exclusions []string) {
+	enabled, excluded := newTagSet(tags), newTagSet(exclusions)
+	l.config().updateDebug(func(s *debugState) {
+		s.debug = debug
+		s.enabled = enabled
+		s.excluded = excluded
+	})
 }
 
 // IsDebugMode returns true if the debug flag is on
 func (l *Logger) IsDebugMode() bool {
-	l.Lock()
-	debugMode := l.debug
-	l.Unlock()
-	return debugMode
+	return l.config().loadDebug().debug
 }
 
 // EnableDebugModeFor turn on debug mode for one or more tags
+// Tags are hierarchical, with dot separated segments, enabling debug for "server.db" also enables it for
+// "server.db.pool". Segments can contain the wildcards supported by path.Match, so "server.*.pool" enables
+// debug for "server.db.pool" and "server.cache.pool".
 func (l *Logger) EnableDebugModeFor(tags ...string) {
-	l.Lock()
-	for _, t := range tags {
-		l.debugTags = append(l.debugTags, t)
-	}
-	l.Unlock()
+	l.config().updateDebug(func(s *debugState) {
+		s.enabled = s.enabled.with(tags)
+	})
 }
 
-// DisableDebugModeFor turns off debug mode for one or more tags
+// DisableDebugModeFor turns off debug mode for one or more tags, the tags must match the ones
+// passed to EnableDebugModeFor exactly
 func (l *Logger) DisableDebugModeFor(tags ...string) {
-	l.Lock()
-	tagSet := map[string]bool{}
-	for _, t := range l.debugTags {
-		tagSet[t] = true
-	}
-	for _, t := range tags {
-		delete(tagSet, t)
-	}
-	newTags := make([]string, 0, len(tagSet))
-	for t := range tagSet {
-		newTags = append(newTags, t)
-	}
-	l.debugTags = newTags
-	l.Unlock()
+	l.config().updateDebug(func(s *debugState) {
+		s.enabled = s.enabled.without(tags)
+	})
+}
+
+// DebugModeTags returns the tags that have debug mode on
+func (l *Logger) DebugModeTags() []string {
+	return l.config().loadDebug().enabled.list()
+}
+
+// ExcludeDebugModeFor turns off debug mode for one or more tags even when the debug flag is on, or debug mode
+// is on for another tag on the same call. Exclusions always win: a debug call with any excluded tag is not printed.
+// Exclusions are matched like EnableDebugModeFor, so excluding "server.heartbeat" also excludes "server.heartbeat.ping".
+func (l *Logger) ExcludeDebugModeFor(tags ...string) {
+	l.config().updateDebug(func(s *debugState) {
+		s.excluded = s.excluded.with(tags)
+	})
 }
 
-// IsDebugModeFor returns true if the debug flag is on for a specific tag
+// RemoveDebugModeExclusionFor removes one or more exclusions added with ExcludeDebugModeFor, the tags must
+// match the excluded ones exactly
+func (l *Logger) RemoveDebugModeExclusionFor(tags ...string) {
+	l.config().updateDebug(func(s *debugState) {
+		s.excluded = s.excluded.without(tags)
+	})
+}
+
+// DebugModeExclusions returns the tags excluded from debug mode
+func (l *Logger) DebugModeExclusions() []string {
+	return l.config().loadDebug().excluded.list()
+}
+
+// IsDebugModeFor returns true if the debug flag is on for a specific tag and the tag isn't excluded, see
+// EnableDebugModeFor for how tags are matched
 func (l *Logger) IsDebugModeFor(tag string) bool {
-	l.Lock()
-	debugMode := l.debug
-	if !debugMode {
-		ld := len(l.debugTags)
-		for i := 0; i < ld; i++ {
-			if l.debugTags[i] == tag {
-				debugMode = true
-				break
-			}
-		}
-	}
-	l.Unlock()
-	return debugMode
+	return l.config().loadDebug().isOnFor([]string{tag})
 }
 
 // Configure set the formatter and appender
 func (l *Logger) Configure(formatter LogFormatter, appender LogAppender) {
-	l.Lock()
-	l.format = formatter
-	l.appender = appender
-	l.Unlock()
+	l.setAppender(newAppender(formatter, appender), formatter, appender, nil, nil)
+}
+
+// ConfigureEntryAppender replaces the formatter and appender with an EntryAppender, which will be
+// passed unformatted entries
+func (l *Logger) ConfigureEntryAppender(appender EntryAppender) {
+	l.setAppender(appender, nil, nil, nil, nil)
+}
+
+// ConfigureWithDebugMode replaces the debug flag, debug tags and exclusions, like SetDebugMode, and the formatter
+// and appender, like Configure, in a single update, so that no entry that sees the new debug state goes to the old appender
+func (l *Logger) ConfigureWithDebugMode(formatter LogFormatter, appender LogAppender, debug bool, tags []string, exclusions []string) {
+	enabled, excluded := newTagSet(tags), newTagSet(exclusions)
+	l.setAppender(newAppender(formatter, appender), formatter, appender, nil, func(s *debugState) {
+		s.debug = debug
+		s.enabled = enabled
+		s.excluded = excluded
+	})
+}
+
+// setAppender replaces the appender, and the debug state if change isn't nil, and closes the file opened for
+// the environment if it isn't the new one
+func (l *Logger) setAppender(app EntryAppender, formatter LogFormatter, appender LogAppender, envFile *FileAppender, change func(s *debugState)) {
+	c := l.config()
+	c.Lock()
+	if change != nil {
+		c.changeDebug(change)
+	}
+	c.appender = app
+	c.formatter = formatter
+	c.logAppender = appender
+	old := c.envFile
+	c.envFile = envFile
+	c.Unlock()
+
+	if old != nil && old != envFile {
+		old.Close()
+	}
+}
+
+// EnvOutput returns the file opened by ApplyEnvPrefix for a file: output, so that it can be passed to HandleSignals
+// as a Reopener. It returns nil if the output isn't a file, or the appender has been replaced since, which closes the file.
+func (l *Logger) EnvOutput() *FileAppender {
+	c := l.config()
+	c.RLock()
+	defer c.RUnlock()
+	return c.envFile
+}
+
+// EnableCallerCapture adds the file, line and function of the logging call to each entry. Skip is the number of
+// extra frames to skip, so that wrappers around the logger can report their caller, 0 reports the code that called
+// the logger. The caller is only captured after the debug checks pass, so debug calls that don't print pay nothing,
+// unless they are passed to a suppressed debug appender.
+func (l *Logger) EnableCallerCapture(skip int) {
+	c := l.config()
+	c.Lock()
+	c.captureCaller = true
+	c.callerSkip = skip
+	c.Unlock()
+}
+
+// DisableCallerCapture stops adding callers to entries
+func (l *Logger) DisableCallerCapture() {
+	c := l.config()
+	c.Lock()
+	c.captureCaller = false
+	c.callerSkip = 0
+	c.Unlock()
 }
 
-//Printf used for most logging, prints the formatted string with the configured formatter
-//A nil logger will do nothing
+// SetClock replaces the function used to get the time for each entry, so that tests can produce
+// deterministic timestamps. A nil clock restores time.Now. The clock is shared with child loggers.
+func (l *Logger) SetClock(clock func() time.Time) {
+	c := l.config()
+	c.Lock()
+	c.clock = clock
+	c.Unlock()
+}
+
+// SetSuppressedDebugAppender passes the entries for debug calls that don't pass the debug checks to the appender,
+// instead of dropping them, so that debug output can be recorded, for example by extras.FlightRecorder, without being
+// printed. Entries are created for every debug call while an appender is set, including the caller if caller capture
+// is on. A nil appender restores dropping them. The appender is shared with child loggers.
+func (l *Logger) SetSuppressedDebugAppender(appender EntryAppender) {
+	if appender == nil {
+		l.config().suppressed.Store(nil)
+		return
+	}
+	l.config().suppressed.Store(&appender)
+}
+
+// Configuration returns the formatter and appender passed to Configure, both are nil if
+// an EntryAppender was configured instead
+func (l *Logger) Configuration() (LogFormatter, LogAppender) {
+	c := l.config()
+	c.RLock()
+	defer c.RUnlock()
+	return c.formatter, c.logAppender
+}
+
+// Printf used for most logging, prints the formatted string with the configured formatter
+// A nil logger will do nothing
 func (l *Logger) Printf(fmt string, args ...interface{}) error {
 	if l == nil {
 		return nil
 	}
-	l.RLock()
-	if l.appender == nil {
-		l.RUnlock()
-		return nil
-	}
-	entry := l.format(false, nil, time.Now(), fmt, args...)
-	app := l.appender
-	l.RUnlock()
-	return app(entry)
+	return l.output(1, false, noStack, l.tags, fmt, args)
 }
 
-//Debugf prints the formatted string with the configured formatter, if debug is on
+// Debugf prints the formatted string with the configured formatter, if debug is on
+// For a logger with tags, debug is also on if it is on for any of the logger's tags
 func (l *Logger) Debugf(fmt string, args ...interface{}) error {
 	if l == nil {
 		return nil
 	}
-	l.RLock()
-	if !l.debug || l.appender == nil {
-		l.RUnlock()
-		return nil
-	}
-	entry := l.format(true, nil, time.Now(), fmt, args...)
-	app := l.appender
-	l.RUnlock()
-	return app(entry)
+	return l.debugf(1, fmt, args)
 }
 
-//TagPrintf used for most logging, prints the formatted string with the configured formatter
+// TagPrintf used for most logging, prints the formatted string with the configured formatter
 func (l *Logger) TagPrintf(tags []string, fmt string, args ...interface{}) error {
 	if l == nil {
 		return nil
 	}
-	l.RLock()
-	if l.appender == nil {
-		l.RUnlock()
-		return nil
-	}
-	entry := l.format(false, tags, time.Now(), fmt, args...)
-	app := l.appender
-	l.RUnlock()
-	return app(entry)
+	return l.output(1, false, noStack, mergeTags(l.tags, tags), fmt, args)
 }
 
-//TagDebugf prints the formatted string with the configured formatter, if debug mode is on for any of the tags
+// TagDebugf prints the formatted string with the configured formatter, if debug mode is on for any of the tags
+// and none of the tags are excluded
 func (l *Logger) TagDebugf(tags []string, fmt string, args ...interface{}) error {
 	if l == nil {
 		return nil
 	}
-	l.RLock()
-	if l.appender == nil {
-		l.RUnlock()
+	return l.tagDebugf(1, tags, fmt, args)
+}
+
+// debugf checks the debug state for the logger's tags, or the global flag if it has none, before calling output,
+// depth is the number of frames between debugf and the code that called the logger
+func (l *Logger) debugf(depth int, format string, args []interface{}) error {
+	if len(l.tags) > 0 || l.forceDebug {
+		return l.tagDebugf(depth+1, nil, format, args)
+	}
+	if !l.config().loadDebug().debug {
+		return l.suppress(depth+1, nil, nil, format, args)
+	}
+	return l.output(depth+1, true, noStack, nil, format, args)
+}
+
+// tagDebugf checks the debug state for the tags and the logger's tags before calling output, unless the logger forces debug.
+// depth is the number of frames between tagDebugf and the code that called the logger
+func (l *Logger) tagDebugf(depth int, tags []string, format string, args []interface{}) error {
+	if l.forceDebug {
+		return l.output(depth+1, true, noStack, mergeTags(l.tags, tags), format, args)
+	}
+	state := l.config().loadDebug()
+	if !state.debug && state.enabled.empty() {
+		return l.suppress(depth+1, l.tags, tags, format, args)
+	}
+	tags = mergeTags(l.tags, tags)
+	if !state.isOnFor(tags) {
+		return l.suppress(depth+1, tags, nil, format, args)
+	}
+	return l.output(depth+1, true, noStack, tags, format, args)
+}
+
+// suppress passes a debug entry that didn't pass the debug checks to the suppressed debug appender, if there is one.
+// The entry's tags are the tags followed by the call tags, they are only merged if there is an appender.
+// depth is the number of frames between suppress and the code that called the logger
+func (l *Logger) suppress(depth int, tags []string, callTags []string, format string, args []interface{}) error {
+	app := l.config().suppressed.Load()
+	if app == nil {
 		return nil
 	}
-	debugMode := l.debug
-	if !debugMode {
-		ld, lt := len(l.debugTags), len(tags)
+	return l.outputTo(*app, depth+1, true, noStack, mergeTags(tags, callTags), format, args)
+}
 
-		for i := 0; i < ld; i++ {
-			for j := 0; j < lt; j++ {
-				if l.debugTags[i] == tags[j] {
-					debugMode = true
-					break
-				}
-			}
-			if debugMode {
-				break
-			}
-		}
+// output creates an entry and passes it to the appender, all of the debug checks have already passed.
+// depth is the number of frames between output and the code that called the logger
+func (l *Logger) output(depth int, debug bool, stack stackMode, tags []string, format string, args []interface{}) error {
+	return l.outputTo(nil, depth+1, debug, stack, tags, format, args)
+}
+
+// outputTo creates an entry and passes it to the appender, or the configured appender if it is nil.
+// depth is the number of frames between outputTo and the code that called the logger
+func (l *Logger) outputTo(app EntryAppender, depth int, debug bool, stack stackMode, tags []string, format string, args []interface{}) error {
+	c := l.config()
+	c.RLock()
+	if app == nil {
+		app = c.appender
 	}
-	if !debugMode {
-		l.RUnlock()
+	captureCaller, callerSkip := c.captureCaller, c.callerSkip
+	clock := c.clock
+	c.RUnlock()
+
+	if app == nil {
 		return nil
 	}
-	entry := l.format(true, tags, time.Now(), fmt, args...)
-	app := l.appender
-	l.RUnlock()
+
+	now := time.Now
+	if clock != nil {
+		now = clock
+	}
+
+	entry := l.newEntry(now(), debug, tags, format, args)
+	if captureCaller {
+		entry.Caller = callerAt(depth + 1 + callerSkip)
+	}
+
+	switch stack {
+	case callerStack:
+		entry.Stack = stackAt(depth + 1 + callerSkip)
+	case allStacks:
+		entry.Goroutines = allGoroutineStacks()
+	}
+
 	return app(entry)
 }
 
-// FullFormat includes everything
+// FullFormat includes everything, including the caller if caller capture is enabled and any stack
 func FullFormat(debug bool, tags []string, t time.Time, format string, args ...interface{}) string {
+	return fullFormat(DefaultTimeFormat, debug, tags, t, format, args)
+}
+
+// NewFullFormatter returns FullFormat with the time formatted by the TimeFormatter, nil uses DefaultTimeFormat
+func NewFullFormatter(timeFormat TimeFormatter) LogFormatter {
+	timeFormat = timeFormat.orDefault()
+	return func(debug bool, tags []string, t time.Time, format string, args ...interface{}) string {
+		return fullFormat(timeFormat, debug, tags, t, format, args)
+	}
+}
+
+func fullFormat(timeFormat TimeFormatter, debug bool, tags []string, t time.Time, format string, args []interface{}) string {
 	formatStr := ""
-	timeStr := t.Format(time.StampMilli)
+	timeStr := strings.ReplaceAll(timeFormat(t), "%", "%%")
 	modeStr := "[INF]"
 
 	if debug {
 		modeStr = "[DBG]"
 	}
 
+	format, args, entry := SplitEntry(format, args)
+	if entry != nil && entry.Caller != nil {
+		modeStr = modeStr + " " + strings.ReplaceAll(entry.Caller.String(), "%", "%%")
+	}
+
 	if tags != nil && len(tags) > 0 {
 		formatStr = fmt.Sprintf("%s %s [%s] %s", timeStr, modeStr, strings.Join(tags, ", "), format)
 	} else {
 		formatStr = fmt.Sprintf("%s %s %s", timeStr, modeStr, format)
 	}
 
+	if entry != nil {
+		return fmt.Sprintf(formatStr, args...) + fmt.Sprint(entryArg{entry: entry})
+	}
 	return fmt.Sprintf(formatStr, args...)
 }
 
 // SimpleFormat includes time, debug and message, but not the tags
 func SimpleFormat(debug bool, tags []string, t time.Time, format string, args ...interface{}) string {
+	return simpleFormat(DefaultTimeFormat, debug, t, format, args)
+}
+
+// NewSimpleFormatter returns SimpleFormat with the time formatted by the TimeFormatter, nil uses DefaultTimeFormat
+func NewSimpleFormatter(timeFormat TimeFormatter) LogFormatter {
+	timeFormat = timeFormat.orDefault()
+	return func(debug bool, tags []string, t time.Time, format string, args ...interface{}) string {
+		return simpleFormat(timeFormat, debug, t, format, args)
+	}
+}
+
+func simpleFormat(timeFormat TimeFormatter, debug bool, t time.Time, format string, args []interface{}) string {
 	formatStr := ""
-	timeStr := t.Format(time.StampMilli)
+	timeStr := strings.ReplaceAll(timeFormat(t), "%", "%%")
 	modeStr := "[INF]"
 
 	if debug {
 		modeStr = "[DBG]"
 	}
 
+	format, args, entry := SplitEntry(format, args)
 	formatStr = fmt.Sprintf("%s %s %s", timeStr, modeStr, format)
 
+	if entry != nil {
+		return fmt.Sprintf(formatStr, args...) + fmt.Sprint(entryArg{entry: entry})
+	}
 	return fmt.Sprintf(formatStr, args...)
 }
 
 // MinimalFormat just formats the message, tags and time are ignored
 func MinimalFormat(debug bool, tags []string, t time.Time, format string, args ...interface{}) string {
+	format, args, entry := SplitEntry(format, args)
+	if entry != nil {
+		return fmt.Sprintf(format, args...) + fmt.Sprint(entryArg{entry: entry})
+	}
 	return fmt.Sprintf(format, args...)
 }
 
+// formatters maps the names accepted by FormatterByName to formatters, "console" is resolved when it is asked for
+var formatters = map[string]LogFormatter{
+	"full":    FullFormat,
+	"simple":  SimpleFormat,
+	"minimal": MinimalFormat,
+	"json":    JSONFormat,
+	"logfmt":  LogfmtFormat,
+	"color":   ColorFormat,
+}
+
+// FormatterByName returns the built-in formatter with the name, "full", "simple", "minimal", "json", "logfmt",
+// "color" or "console". The console formatter is NewConsoleFormatter for stderr, use FormatterByNameFor if the
+// appender writes somewhere else.
+func FormatterByName(name string) (LogFormatter, error) {
+	return FormatterByNameFor(name, os.Stderr)
+}
+
+// FormatterByNameFor is FormatterByName with the console formatter checking the file the appender writes to,
+// a nil file, for appenders that don't write to a terminal, gets the console formatter without color unless
+// FORCE_COLOR is set. The file is checked each time "console" is asked for.
+func FormatterByNameFor(name string, f *os.File) (LogFormatter, error) {
+	lower := strings.ToLower(name)
+	if lower == "console" {
+		return NewConsoleFormatter(f, nil), nil
+	}
+	formatter, ok := formatters[lower]
+	if !ok {
+		return nil, fmt.Errorf("unknown formatter %q", name)
+	}
+	return formatter, nil
+}
+
 // StdErrAppender is an appender for stderr
 func StdErrAppender(entry string) error {
 	fmt.Fprintln(os.Stderr, entry)
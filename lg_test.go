@@ -3,6 +3,7 @@ package lg
 import (
 	"log"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -42,6 +43,39 @@ func TestNilLogger(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestZeroValueLogger(t *testing.T) {
+	logger := &Logger{}
+	require.NoError(t, logger.Printf("no appender"))
+	require.NoError(t, logger.Debugf("debug off"))
+	require.False(t, logger.IsDebugMode())
+
+	a := &ArrayAppender{}
+	logger.Configure(MinimalFormat, a.Log)
+	logger.Printf("one")
+	logger.Debugf("dropped")
+	logger.EnableDebugMode()
+	logger.Debugf("two")
+	logger.With("k", "v").TagPrintf([]string{"red"}, "three")
+	require.Equal(t, []string{"one", "two", "three k=v"}, a.Entries)
+
+	var value Logger
+	value.EnableDebugModeFor("red")
+	require.True(t, value.IsDebugModeFor("red"))
+	require.Nil(t, value.With("k", "v").config().appender)
+
+	var wg sync.WaitGroup
+	shared := &Logger{}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			shared.EnableDebugModeFor("red")
+		}()
+	}
+	wg.Wait()
+	require.True(t, shared.IsDebugModeFor("red"))
+}
+
 func TestFullFormatDebugOff(t *testing.T) {
 	a := &ArrayAppender{}
 
@@ -454,3 +488,131 @@ func BenchmarkTagDebugWithDebugOn(b *testing.B) {
 		logger.TagDebugf(tags, "one %s", "formatted")
 	}
 }
+
+func TestWithFields(t *testing.T) {
+	a := &ArrayAppender{}
+
+	logger := NewLogger()
+	logger.Configure(FullFormat, a.Log)
+	child := logger.With("request_id", "abc123", String("user", "bob smith"))
+
+	child.Printf("one %s", "formatted")
+	logger.Printf("two %s", "formatted")
+	child.TagPrintf([]string{"red"}, "three %s", "formatted")
+
+	require.Equal(t, 3, len(a.Entries))
+	require.True(t, strings.HasSuffix(a.Entries[0], `one formatted request_id=abc123 user="bob smith"`))
+	require.True(t, strings.HasSuffix(a.Entries[1], "two formatted"))
+	require.True(t, strings.Contains(a.Entries[2], "[red]"))
+	require.True(t, strings.HasSuffix(a.Entries[2], `three formatted request_id=abc123 user="bob smith"`))
+
+	grandchild := child.With("user", "alice", Int("shard", 3))
+	grandchild.Printf("four")
+	require.True(t, strings.HasSuffix(a.Entries[3], "four request_id=abc123 user=alice shard=3"))
+	require.Equal(t, Fields{String("request_id", "abc123"), String("user", "bob smith")}, child.Fields())
+}
+
+func TestWithSharesConfiguration(t *testing.T) {
+	a := &ArrayAppender{}
+	b := &ArrayAppender{}
+
+	logger := NewLogger()
+	logger.Configure(MinimalFormat, a.Log)
+	child := logger.With("request_id", 1)
+
+	child.TagDebugf([]string{"red"}, "one")
+	require.Equal(t, 0, len(a.Entries))
+
+	logger.EnableDebugModeFor("red")
+	child.TagDebugf([]string{"red"}, "two")
+	require.Equal(t, 1, len(a.Entries))
+	require.Equal(t, "two request_id=1", a.Entries[0])
+
+	child.DisableDebugModeFor("red")
+	require.False(t, logger.IsDebugModeFor("red"))
+
+	logger.EnableDebugMode()
+	child.Debugf("three")
+	require.Equal(t, 2, len(a.Entries))
+
+	logger.Configure(MinimalFormat, b.Log)
+	child.Printf("four")
+	require.Equal(t, 2, len(a.Entries))
+	require.Equal(t, 1, len(b.Entries))
+}
+
+func TestWithNilLogger(t *testing.T) {
+	var logger *Logger
+	child := logger.With("key", "value")
+	require.Nil(t, child)
+	require.Nil(t, child.Fields())
+	require.NoError(t, child.Printf("test"))
+}
+
+func TestWithTags(t *testing.T) {
+	a := &ArrayAppender{}
+
+	logger := NewLogger()
+	logger.Configure(FullFormat, a.Log)
+	db := logger.WithTags("server", "db")
+
+	db.Printf("one %s", "formatted")
+	db.TagPrintf([]string{"pool", "db"}, "two %s", "formatted")
+	db.Debugf("three %s", "formatted") // debug is off
+	require.Equal(t, 2, len(a.Entries))
+	require.True(t, strings.Contains(a.Entries[0], "[server, db] one formatted"))
+	require.True(t, strings.Contains(a.Entries[1], "[server, db, pool] two formatted"))
+	require.Equal(t, []string{"server", "db"}, db.Tags())
+
+	logger.EnableDebugModeFor("db")
+	db.Debugf("four %s", "formatted")
+	db.TagDebugf([]string{"pool"}, "five %s", "formatted")
+	logger.Debugf("six %s", "formatted") // root has no tags
+	require.Equal(t, 4, len(a.Entries))
+	require.True(t, strings.Contains(a.Entries[2], "[DBG] [server, db] four formatted"))
+	require.True(t, strings.Contains(a.Entries[3], "[DBG] [server, db, pool] five formatted"))
+
+	pool := db.WithTags("pool").With("size", 10)
+	logger.DisableDebugModeFor("db")
+	logger.EnableDebugModeFor("pool")
+	pool.Debugf("seven %s", "formatted")
+	db.Debugf("eight %s", "formatted")
+	require.Equal(t, 5, len(a.Entries))
+	require.True(t, strings.HasSuffix(a.Entries[4], "[server, db, pool] seven formatted size=10"))
+
+	b := &ArrayAppender{}
+	logger.Configure(MinimalFormat, b.Log)
+	pool.Printf("nine")
+	require.Equal(t, []string{"nine size=10"}, b.Entries)
+}
+
+func TestWithTagsNilLogger(t *testing.T) {
+	var logger *Logger
+	child := logger.WithTags("red")
+	require.Nil(t, child)
+	require.Nil(t, child.Tags())
+	require.NoError(t, child.Debugf("test"))
+}
+
+func BenchmarkTaggedLoggerDebugWithDebugOff(b *testing.B) {
+	b.ReportAllocs()
+	logger := NewLogger()
+	logger.Configure(MinimalFormat, NullAppender)
+	tagged := logger.WithTags("red", "blue")
+
+	for n := 0; n < b.N; n++ {
+		tagged.Debugf("one %s", "formatted")
+	}
+}
+
+func BenchmarkTaggedLoggerTagDebugWithDebugOff(b *testing.B) {
+	b.ReportAllocs()
+	logger := NewLogger()
+	logger.Configure(MinimalFormat, NullAppender)
+	tagged := logger.WithTags("server")
+	tags := []string{"red"}
+
+	for n := 0; n < b.N; n++ {
+		tagged.TagDebugf(tags, "one formatted")
+	}
+}
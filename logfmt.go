@@ -0,0 +1,99 @@
+package lg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LogfmtFormat writes each entry as logfmt key=value pairs:
+//
+//	ts=2024-01-02T15:04:05.123456789Z level=debug tags=server,db msg="connected to db1" host=db1
+//
+// The time is formatted with time.RFC3339Nano and the level is "info" or "debug", tags are left out if there
+// are none. If caller capture is on the caller is added as "caller", stacks are added as "stack", with a line
+// for each frame, and goroutine dumps as "goroutines". Values are quoted if they are empty or contain spaces,
+// quotes, an equals sign, control characters or invalid UTF-8. Characters that can't be in a key are replaced
+// with underscores.
+func LogfmtFormat(debug bool, tags []string, t time.Time, format string, args ...interface{}) string {
+	return logfmtFormat(rfc3339Nano, debug, tags, t, format, args)
+}
+
+// NewLogfmtFormatter returns LogfmtFormat with the time formatted by the TimeFormatter, nil uses time.RFC3339Nano
+func NewLogfmtFormatter(timeFormat TimeFormatter) LogFormatter {
+	if timeFormat == nil {
+		timeFormat = rfc3339Nano
+	}
+	return func(debug bool, tags []string, t time.Time, format string, args ...interface{}) string {
+		return logfmtFormat(timeFormat, debug, tags, t, format, args)
+	}
+}
+
+func logfmtFormat(timeFormat TimeFormatter, debug bool, tags []string, t time.Time, format string, args []interface{}) string {
+	format, args, entry := SplitEntry(format, args)
+
+	b := &strings.Builder{}
+	b.WriteString("ts=")
+	b.WriteString(quoteValue(timeFormat(t)))
+
+	if debug {
+		b.WriteString(" level=debug")
+	} else {
+		b.WriteString(" level=info")
+	}
+
+	if len(tags) > 0 {
+		b.WriteString(" tags=")
+		b.WriteString(quoteValue(strings.Join(tags, ",")))
+	}
+
+	b.WriteString(" msg=")
+	b.WriteString(quoteValue(fmt.Sprintf(format, args...)))
+
+	if entry == nil {
+		return b.String()
+	}
+
+	if entry.Caller != nil {
+		b.WriteString(" caller=")
+		b.WriteString(quoteValue(entry.Caller.String()))
+	}
+
+	if len(entry.Stack) > 0 {
+		frames := make([]string, len(entry.Stack))
+		for i, frame := range entry.Stack {
+			frames[i] = frame.Function + " " + frame.File + ":" + strconv.Itoa(frame.Line)
+		}
+		b.WriteString(" stack=")
+		b.WriteString(quoteValue(strings.Join(frames, "\n")))
+	}
+
+	if entry.Goroutines != "" {
+		b.WriteString(" goroutines=")
+		b.WriteString(quoteValue(entry.Goroutines))
+	}
+
+	for _, f := range entry.Fields {
+		b.WriteString(" ")
+		b.WriteString(logfmtKey(f.Key))
+		b.WriteString("=")
+		b.WriteString(quoteValue(fieldValueString(f.Value)))
+	}
+
+	return b.String()
+}
+
+// logfmtKey replaces spaces, quotes, equals signs and control characters in a key with underscores,
+// an empty key becomes a single underscore
+func logfmtKey(key string) string {
+	if key == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || r == 0x7f {
+			return '_'
+		}
+		return r
+	}, key)
+}
@@ -0,0 +1,50 @@
+package lg
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogfmtFormat(t *testing.T) {
+	now := time.Date(2024, 1, 2, 15, 4, 5, 123456789, time.UTC)
+
+	require.Equal(t, `ts=2024-01-02T15:04:05.123456789Z level=info msg=hello`, LogfmtFormat(false, nil, now, "hello"))
+	require.Equal(t, `ts=2024-01-02T15:04:05.123456789Z level=debug tags=server,db msg="hello world"`,
+		LogfmtFormat(true, []string{"server", "db"}, now, "hello %s", "world"))
+	require.Equal(t, `ts=2024-01-02T15:04:05.123456789Z level=info tags="a b" msg=""`, LogfmtFormat(false, []string{"a b"}, now, ""))
+
+	entry := &Entry{
+		Time:   now,
+		Format: "x=%d",
+		Args:   []interface{}{1},
+		Fields: Fields{
+			String("quote", `say "hi"`),
+			String("empty", ""),
+			Err(errors.New("bad\nthing")),
+			Int("n", 5),
+			String("bad key=", "v"),
+			String("", "v"),
+		},
+		Caller: &Caller{File: "/src/server/main.go", Line: 12},
+	}
+	require.Equal(t, `ts=2024-01-02T15:04:05.123456789Z level=info msg="x=1" caller=server/main.go:12 `+
+		`quote="say \"hi\"" empty="" error="bad\nthing" n=5 bad_key_=v _=v`, entry.FormatWith(LogfmtFormat))
+
+	entry = &Entry{
+		Time:   now,
+		Format: "boom",
+		Stack: []Caller{
+			{Function: "main.inner", File: "/src/main.go", Line: 10},
+			{Function: "main.main", File: "/src/main.go", Line: 20},
+		},
+	}
+	require.Equal(t, `ts=2024-01-02T15:04:05.123456789Z level=info msg=boom stack="main.inner /src/main.go:10\nmain.main /src/main.go:20"`,
+		entry.FormatWith(LogfmtFormat))
+
+	formatter, err := FormatterByName("logfmt")
+	require.NoError(t, err)
+	require.Equal(t, entry.FormatWith(LogfmtFormat), entry.FormatWith(formatter))
+}
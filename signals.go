@@ -0,0 +1,117 @@
+package lg
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+)
+
+// signalTags are the tags used when a SignalHandler logs a change
+var signalTags = []string{"lg.signal"}
+
+// Reopener is implemented by appenders that can reopen their files, like FileAppender and the
+// RollingFileAppender in extras
+type Reopener interface {
+	Reopen() error
+}
+
+// SignalOptions configures HandleSignals, see DefaultSignalOptions for the defaults
+type SignalOptions struct {
+	// Toggle signals switch the debug flag between EnableDebugMode and DisableDebugMode
+	Toggle []os.Signal
+	// Reopen signals call Reopen on each of the Reopeners
+	Reopen    []os.Signal
+	Reopeners []Reopener
+}
+
+// SignalHandler is returned by HandleSignals and handles signals until it is stopped
+type SignalHandler struct {
+	logger   *Logger
+	options  SignalOptions
+	signals  chan os.Signal
+	stop     chan struct{}
+	stopped  chan struct{}
+	stopOnce sync.Once
+}
+
+// HandleSignals starts a goroutine that changes the logger, or reopens files, when the process receives the
+// signals in the options. Each change is logged through the logger with the tag "lg.signal".
+// Signals that are in both lists do both.
+func HandleSignals(logger *Logger, options SignalOptions) *SignalHandler {
+	handler := &SignalHandler{
+		logger:  logger,
+		options: options,
+		signals: make(chan os.Signal, 4),
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	all := append(append([]os.Signal{}, options.Toggle...), options.Reopen...)
+	if len(all) > 0 {
+		signal.Notify(handler.signals, all...)
+	}
+
+	go handler.run()
+
+	return handler
+}
+
+func (h *SignalHandler) run() {
+	defer close(h.stopped)
+
+	for {
+		select {
+		case <-h.stop:
+			return
+		case sig := <-h.signals:
+			h.handle(sig)
+		}
+	}
+}
+
+// handle applies a single signal
+func (h *SignalHandler) handle(sig os.Signal) {
+	if containsSignal(h.options.Toggle, sig) {
+		if h.logger.IsDebugMode() {
+			h.logger.DisableDebugMode()
+			h.logger.TagPrintf(signalTags, "debug mode disabled by %v", sig)
+		} else {
+			h.logger.EnableDebugMode()
+			h.logger.TagPrintf(signalTags, "debug mode enabled by %v", sig)
+		}
+	}
+
+	if containsSignal(h.options.Reopen, sig) {
+		failed := 0
+		for _, r := range h.options.Reopeners {
+			err := r.Reopen()
+			if err != nil {
+				failed++
+				h.logger.TagPrintf(signalTags, "error reopening log file after %v: %v", sig, err)
+			}
+		}
+		if failed == 0 {
+			h.logger.TagPrintf(signalTags, "log files reopened by %v", sig)
+		} else {
+			h.logger.TagPrintf(signalTags, "%d of %d log files failed to reopen after %v", failed, len(h.options.Reopeners), sig)
+		}
+	}
+}
+
+// Stop stops handling signals, signals received afterwards get their default behavior
+func (h *SignalHandler) Stop() {
+	h.stopOnce.Do(func() {
+		signal.Stop(h.signals)
+		close(h.stop)
+	})
+	<-h.stopped
+}
+
+func containsSignal(signals []os.Signal, sig os.Signal) bool {
+	for _, s := range signals {
+		if s == sig {
+			return true
+		}
+	}
+	return false
+}
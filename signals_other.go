@@ -0,0 +1,10 @@
+//go:build !unix
+
+package lg
+
+// DefaultSignalOptions doesn't handle any signals on platforms without SIGUSR1 and SIGHUP
+func DefaultSignalOptions(reopeners ...Reopener) SignalOptions {
+	return SignalOptions{
+		Reopeners: reopeners,
+	}
+}
@@ -0,0 +1,88 @@
+//go:build unix
+
+package lg
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type badReopener struct{}
+
+func (badReopener) Reopen() error {
+	return errors.New("can't reopen")
+}
+
+func TestHandleSignals(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lgsignal")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "out.log")
+
+	file, err := NewFileAppender(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	logger := NewLoggerWithConfig(MinimalFormat, file.Log)
+	handler := HandleSignals(logger, DefaultSignalOptions(file, badReopener{}))
+	defer handler.Stop()
+
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGUSR1))
+	require.Eventually(t, logger.IsDebugMode, time.Second, 5*time.Millisecond)
+
+	require.NoError(t, os.Rename(path, path+".1"))
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}, time.Second, 5*time.Millisecond)
+
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGUSR1))
+	require.Eventually(t, func() bool { return !logger.IsDebugMode() }, time.Second, 5*time.Millisecond)
+
+	handler.Stop()
+	handler.Stop()
+
+	rotated, err := ioutil.ReadFile(path + ".1")
+	require.NoError(t, err)
+	require.Equal(t, "debug mode enabled by user defined signal 1\n", string(rotated))
+
+	current, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(current)), "\n")
+	require.Equal(t, []string{
+		"error reopening log file after hangup: can't reopen",
+		"1 of 2 log files failed to reopen after hangup",
+		"debug mode disabled by user defined signal 1",
+	}, lines)
+}
+
+type goodReopener struct{}
+
+func (goodReopener) Reopen() error {
+	return nil
+}
+
+func TestReopenResult(t *testing.T) {
+	a := &ArrayAppender{}
+	logger := NewLoggerWithConfig(MinimalFormat, a.Log)
+
+	handler := &SignalHandler{logger: logger, options: SignalOptions{
+		Reopen:    []os.Signal{syscall.SIGHUP},
+		Reopeners: []Reopener{goodReopener{}, goodReopener{}},
+	}}
+	handler.handle(syscall.SIGHUP)
+	require.Equal(t, []string{"log files reopened by hangup"}, a.Entries)
+
+	handler.options.Reopeners = []Reopener{badReopener{}, goodReopener{}, badReopener{}}
+	handler.handle(syscall.SIGHUP)
+	require.Equal(t, "2 of 3 log files failed to reopen after hangup", a.Entries[len(a.Entries)-1])
+}
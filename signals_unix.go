@@ -0,0 +1,17 @@
+//go:build unix
+
+package lg
+
+import (
+	"os"
+	"syscall"
+)
+
+// DefaultSignalOptions toggles debug mode on SIGUSR1 and reopens the appenders on SIGHUP
+func DefaultSignalOptions(reopeners ...Reopener) SignalOptions {
+	return SignalOptions{
+		Toggle:    []os.Signal{syscall.SIGUSR1},
+		Reopen:    []os.Signal{syscall.SIGHUP},
+		Reopeners: reopeners,
+	}
+}
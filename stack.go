@@ -0,0 +1,111 @@
+package lg
+
+import (
+	"bytes"
+	"io"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// stackMode controls what stack information output adds to an entry
+type stackMode int
+
+const (
+	noStack stackMode = iota
+	callerStack
+	allStacks
+)
+
+// maxStackDepth limits the number of frames captured for an entry
+const maxStackDepth = 64
+
+// maxAllStacksSize limits the size of the dump captured for all goroutines
+const maxAllStacksSize = 64 << 20
+
+// PrintStackf prints the formatted string with the stack of the calling goroutine, starting at the caller
+func (l *Logger) PrintStackf(fmt string, args ...interface{}) error {
+	if l == nil {
+		return nil
+	}
+	return l.output(1, false, callerStack, l.tags, fmt, args)
+}
+
+// TagPrintStackf prints the formatted string, with tags, and the stack of the calling goroutine, starting at the caller
+func (l *Logger) TagPrintStackf(tags []string, fmt string, args ...interface{}) error {
+	if l == nil {
+		return nil
+	}
+	return l.output(1, false, callerStack, mergeTags(l.tags, tags), fmt, args)
+}
+
+// PrintAllStacksf prints the formatted string with the stacks of every goroutine, as returned by runtime.Stack.
+// This stops the world while the stacks are collected, and can produce a very large entry, so it is meant for
+// catastrophic situations.
+func (l *Logger) PrintAllStacksf(fmt string, args ...interface{}) error {
+	if l == nil {
+		return nil
+	}
+	return l.output(1, false, allStacks, l.tags, fmt, args)
+}
+
+// stackAt returns the frames of the current goroutine starting skip frames above the function calling stackAt,
+// runtime.goexit is left off the end
+func stackAt(skip int) []Caller {
+	pcs := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(skip+2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	stack := make([]Caller, 0, n)
+	for {
+		frame, more := frames.Next()
+		if frame.Function != "runtime.goexit" {
+			stack = append(stack, Caller{
+				File:     frame.File,
+				Line:     frame.Line,
+				Function: frame.Function,
+			})
+		}
+		if !more {
+			break
+		}
+	}
+
+	return stack
+}
+
+// allGoroutineStacks returns the stacks of every goroutine, growing the buffer until they fit
+func allGoroutineStacks() string {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) || len(buf) >= maxAllStacksSize {
+			return string(bytes.TrimRight(buf[:n], "\n"))
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// writeStack writes the entry's stack, or goroutine dump, as a block indented with tabs starting on a new line
+func writeStack(w io.Writer, e *Entry) {
+	for _, frame := range e.Stack {
+		io.WriteString(w, "\n\t")
+		io.WriteString(w, frame.Function)
+		io.WriteString(w, "\n\t\t")
+		io.WriteString(w, frame.File)
+		io.WriteString(w, ":")
+		io.WriteString(w, strconv.Itoa(frame.Line))
+	}
+
+	if e.Goroutines != "" {
+		io.WriteString(w, "\n\t")
+		io.WriteString(w, strings.ReplaceAll(e.Goroutines, "\n", "\n\t"))
+	}
+}
+
+// stackString returns the block written by writeStack
+func stackString(e *Entry) string {
+	b := &strings.Builder{}
+	writeStack(b, e)
+	return b.String()
+}
@@ -0,0 +1,95 @@
+package lg
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// stackWrapper adds a frame between the test and the logger so the stack has more than one frame from the test
+func stackWrapper(logger *Logger) {
+	logger.PrintStackf("from %s", "wrapper")
+}
+
+func TestPrintStackf(t *testing.T) {
+	a := &EntryArrayAppender{}
+	logger := NewLoggerWithEntryAppender(a.Append)
+
+	stackWrapper(logger)
+	logger.TagPrintStackf([]string{"red"}, "tagged")
+
+	require.Len(t, a.Entries, 2)
+
+	stack := a.Entries[0].Stack
+	require.True(t, len(stack) >= 2)
+	require.Equal(t, "github.com/sasbury/lg.stackWrapper", stack[0].Function)
+	require.Equal(t, 13, stack[0].Line)
+	require.True(t, strings.HasSuffix(stack[0].File, "stack_test.go"), stack[0].File)
+	require.Equal(t, "github.com/sasbury/lg.TestPrintStackf", stack[1].Function)
+	require.Equal(t, 20, stack[1].Line)
+	require.Equal(t, "from wrapper", a.Entries[0].Message())
+
+	for _, frame := range stack {
+		require.NotEqual(t, "runtime.goexit", frame.Function)
+	}
+
+	stack = a.Entries[1].Stack
+	require.Equal(t, "github.com/sasbury/lg.TestPrintStackf", stack[0].Function)
+	require.Equal(t, 21, stack[0].Line)
+	require.Equal(t, []string{"red"}, a.Entries[1].Tags)
+
+	logger.EnableCallerCapture(1)
+	stackWrapper(logger)
+	require.Equal(t, "github.com/sasbury/lg.TestPrintStackf", a.Entries[2].Stack[0].Function)
+	require.Equal(t, "github.com/sasbury/lg.TestPrintStackf", a.Entries[2].Caller.Function)
+
+	var nilLogger *Logger
+	require.NoError(t, nilLogger.PrintStackf("nil"))
+	require.NoError(t, nilLogger.TagPrintStackf(nil, "nil"))
+	require.NoError(t, nilLogger.PrintAllStacksf("nil"))
+}
+
+func TestPrintAllStacksf(t *testing.T) {
+	a := &EntryArrayAppender{}
+	logger := NewLoggerWithEntryAppender(a.Append)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		<-done
+	}()
+
+	logger.PrintAllStacksf("all")
+
+	require.Len(t, a.Entries, 1)
+	require.Empty(t, a.Entries[0].Stack)
+	dump := a.Entries[0].Goroutines
+	require.True(t, strings.HasPrefix(dump, "goroutine "), dump)
+	require.Contains(t, dump, "TestPrintAllStacksf")
+	require.True(t, strings.Count(dump, "goroutine ") >= 2)
+	require.False(t, strings.HasSuffix(dump, "\n"))
+}
+
+func TestStackFormatting(t *testing.T) {
+	entry := &Entry{
+		Time:   time.Now(),
+		Format: "boom",
+		Fields: Fields{String("k", "v")},
+		Stack: []Caller{
+			{Function: "main.inner", File: "/src/main.go", Line: 10},
+			{Function: "main.main", File: "/src/main.go", Line: 20},
+		},
+	}
+
+	expected := "boom k=v\n\tmain.inner\n\t\t/src/main.go:10\n\tmain.main\n\t\t/src/main.go:20"
+	require.Equal(t, expected, entry.FormatWith(MinimalFormat))
+	require.True(t, strings.HasSuffix(entry.FormatWith(FullFormat), "[INF] "+expected))
+
+	entry = &Entry{
+		Format:     "dump",
+		Goroutines: "goroutine 1 [running]:\nmain.main()",
+	}
+	require.Equal(t, "dump\n\tgoroutine 1 [running]:\n\tmain.main()", entry.FormatWith(MinimalFormat))
+}
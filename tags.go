@@ -0,0 +1,75 @@
+package lg
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// tagMatches returns true if the debug tag pattern covers the tag. Tags are hierarchical with
+// dot separated segments, a pattern covers any tag with the same segments and all of the descendants of that
+// tag, so "server.db" covers "server.db" and "server.db.pool" but not "server.dbx". Each segment of the pattern can
+// use the wildcards supported by path.Match, so "server.*.pool" covers "server.db.pool" and "server.cache.pool".
+func tagMatches(pattern string, tag string) bool {
+	if pattern == tag {
+		return true
+	}
+
+	if !isTagPattern(pattern) {
+		return strings.HasPrefix(tag, pattern) && len(tag) > len(pattern) && tag[len(pattern)] == '.'
+	}
+
+	for {
+		pi := strings.IndexByte(pattern, '.')
+		ti := strings.IndexByte(tag, '.')
+
+		patternSegment, tagSegment := pattern, tag
+		if pi >= 0 {
+			patternSegment = pattern[:pi]
+		}
+		if ti >= 0 {
+			tagSegment = tag[:ti]
+		}
+
+		if matched, err := path.Match(patternSegment, tagSegment); err != nil || !matched {
+			return false
+		}
+
+		if pi < 0 {
+			return true // the rest of the tag is a descendant
+		}
+		if ti < 0 {
+			return false // the pattern is more specific than the tag
+		}
+
+		pattern, tag = pattern[pi+1:], tag[ti+1:]
+	}
+}
+
+// isTagPattern returns true if the tag contains any wildcards
+func isTagPattern(tag string) bool {
+	return strings.ContainsAny(tag, "*?[\\")
+}
+
+// ValidateDebugTags returns an error if any of the tags has a wildcard segment that isn't a valid path.Match
+// pattern, such a tag never matches anything. Use it to check tags from configuration before passing them to
+// EnableDebugModeFor, ExcludeDebugModeFor or SetDebugMode.
+func ValidateDebugTags(tags ...string) error {
+	return validateTags(tags)
+}
+
+// validateTags returns an error for the first tag with a wildcard segment that path.Match rejects, tagMatches treats
+// those segments as not matching, so the tag would never turn debug on or exclude anything
+func validateTags(tags []string) error {
+	for _, tag := range tags {
+		if !isTagPattern(tag) {
+			continue
+		}
+		for _, segment := range strings.Split(tag, ".") {
+			if _, err := path.Match(segment, ""); err != nil {
+				return fmt.Errorf("invalid debug tag %q: %v", tag, err)
+			}
+		}
+	}
+	return nil
+}
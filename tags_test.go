@@ -0,0 +1,128 @@
+package lg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTagMatches(t *testing.T) {
+	require.True(t, tagMatches("server", "server"))
+	require.True(t, tagMatches("server", "server.db"))
+	require.True(t, tagMatches("server", "server.db.pool"))
+	require.True(t, tagMatches("server.db", "server.db.pool"))
+	require.False(t, tagMatches("server.db", "server"))
+	require.False(t, tagMatches("server.db", "server.dbx"))
+	require.False(t, tagMatches("server", "serverx"))
+	require.False(t, tagMatches("server", "client.server"))
+
+	require.True(t, tagMatches("server.*.pool", "server.db.pool"))
+	require.True(t, tagMatches("server.*.pool", "server.cache.pool"))
+	require.True(t, tagMatches("server.*.pool", "server.cache.pool.size"))
+	require.False(t, tagMatches("server.*.pool", "server.db"))
+	require.False(t, tagMatches("server.*.pool", "server.db.queue"))
+	require.False(t, tagMatches("server.*.pool", "server.db.cache.pool"))
+	require.True(t, tagMatches("*", "anything.at.all"))
+	require.True(t, tagMatches("server.d?", "server.db"))
+	require.True(t, tagMatches("server.db*", "server.dbx"))
+	require.False(t, tagMatches("server.[", "server.db")) // bad patterns never match
+}
+
+func TestValidateTags(t *testing.T) {
+	require.NoError(t, validateTags(nil))
+	require.NoError(t, validateTags([]string{"server", "server.db", "server.*.pool", "server.d?", "server.[a-c]b"}))
+	require.Error(t, validateTags([]string{"server", "server.[db"}))
+	require.Error(t, validateTags([]string{"server.db\\"}))
+	require.Error(t, validateTags([]string{"[]"}))
+}
+
+func TestHierarchicalDebugTags(t *testing.T) {
+	a := &ArrayAppender{}
+
+	logger := NewLogger()
+	logger.Configure(MinimalFormat, a.Log)
+	logger.EnableDebugModeFor("server.db")
+
+	require.True(t, logger.IsDebugModeFor("server.db"))
+	require.True(t, logger.IsDebugModeFor("server.db.pool"))
+	require.False(t, logger.IsDebugModeFor("server"))
+	require.False(t, logger.IsDebugModeFor("server.cache"))
+
+	logger.TagDebugf([]string{"server.db.pool"}, "one")
+	logger.TagDebugf([]string{"server.cache"}, "two")
+	logger.WithTags("server.db.conn").Debugf("three")
+	require.Equal(t, []string{"one", "three"}, a.Entries)
+
+	logger.DisableDebugModeFor("server.db")
+	logger.EnableDebugModeFor("server.*.pool")
+	require.True(t, logger.IsDebugModeFor("server.db.pool"))
+	require.True(t, logger.IsDebugModeFor("server.cache.pool"))
+	require.False(t, logger.IsDebugModeFor("server.db.conn"))
+
+	logger.TagDebugf([]string{"server.cache.pool"}, "four")
+	logger.TagDebugf([]string{"server.db.conn"}, "five")
+	require.Equal(t, []string{"one", "three", "four"}, a.Entries)
+}
+
+func BenchmarkTagDebugHierarchical(b *testing.B) {
+	b.ReportAllocs()
+	logger := NewLogger()
+	logger.Configure(MinimalFormat, NullAppender)
+	logger.EnableDebugModeFor("server.db")
+	tags := []string{"server.db.pool"}
+
+	for n := 0; n < b.N; n++ {
+		logger.TagDebugf(tags, "one %s", "formatted")
+	}
+}
+
+func BenchmarkTagDebugWildcard(b *testing.B) {
+	b.ReportAllocs()
+	logger := NewLogger()
+	logger.Configure(MinimalFormat, NullAppender)
+	logger.EnableDebugModeFor("server.*.pool")
+	tags := []string{"server.db.pool"}
+
+	for n := 0; n < b.N; n++ {
+		logger.TagDebugf(tags, "one %s", "formatted")
+	}
+}
+
+func TestDebugExclusions(t *testing.T) {
+	a := &ArrayAppender{}
+
+	logger := NewLogger()
+	logger.Configure(MinimalFormat, a.Log)
+	logger.EnableDebugMode()
+	logger.ExcludeDebugModeFor("heartbeat", "server.cache")
+
+	require.True(t, logger.IsDebugMode())
+	require.True(t, logger.IsDebugModeFor("server"))
+	require.False(t, logger.IsDebugModeFor("heartbeat"))
+	require.False(t, logger.IsDebugModeFor("server.cache.evict"))
+	require.Equal(t, []string{"heartbeat", "server.cache"}, logger.DebugModeExclusions())
+
+	logger.Debugf("one")
+	logger.TagDebugf([]string{"server"}, "two")
+	logger.TagDebugf([]string{"heartbeat"}, "three")
+	logger.TagDebugf([]string{"server", "heartbeat"}, "four") // excluded tag wins
+	logger.WithTags("heartbeat").Debugf("five")
+	logger.TagPrintf([]string{"heartbeat"}, "six") // exclusions only apply to debug
+	require.Equal(t, []string{"one", "two", "six"}, a.Entries)
+
+	logger.DisableDebugMode()
+	logger.EnableDebugModeFor("server")
+	logger.TagDebugf([]string{"server.db"}, "seven")
+	logger.TagDebugf([]string{"server.cache"}, "eight") // excluded even though server is on
+	require.Equal(t, []string{"one", "two", "six", "seven"}, a.Entries)
+	require.Equal(t, []string{"server"}, logger.DebugModeTags())
+
+	logger.RemoveDebugModeExclusionFor("server.cache")
+	logger.TagDebugf([]string{"server.cache"}, "nine")
+	require.Equal(t, []string{"one", "two", "six", "seven", "nine"}, a.Entries)
+	require.Equal(t, []string{"heartbeat"}, logger.DebugModeExclusions())
+
+	logger.DisableDebugModeAll()
+	require.Empty(t, logger.DebugModeExclusions())
+	require.Empty(t, logger.DebugModeTags())
+}
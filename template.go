@@ -0,0 +1,261 @@
+package lg
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TemplateOptions configures the labels and separators used by NewTemplateFormatter, empty settings use the defaults
+type TemplateOptions struct {
+	InfoLabel    string // defaults to "INF"
+	DebugLabel   string // defaults to "DBG"
+	TagSeparator string // defaults to ", "
+
+	// Time formats the time for %{time} without a layout, defaults to DefaultTimeFormat
+	Time TimeFormatter
+}
+
+// templateSegment writes part of an entry
+type templateSegment func(b *strings.Builder, e *templateEntry)
+
+// templateEntry holds an entry while it is formatted, the message is rendered once
+type templateEntry struct {
+	debug   bool
+	tags    []string
+	time    time.Time
+	message string
+	entry   *Entry
+}
+
+/*
+NewTemplateFormatter returns a formatter that writes entries with a pattern like:
+
+	%{time:2006-01-02T15:04:05} [%{level}] %{tags:[%s] }%{msg}%{fields: %s}
+
+The pattern is compiled once, so entries are formatted without parsing it again. The directives are:
+
+	%{time}    the time, with a layout as the argument, defaults to the Time option
+	%{level}   the info or debug label
+	%{tags}    the tags joined with the tag separator
+	%{msg}     the message
+	%{fields}  the fields as key=value pairs
+	%{caller}  the caller, if caller capture is on
+	%{stack}   the stack, as the indented block used by FullFormat
+	%%         a percent sign
+
+For tags, fields, caller and stack the argument is a format with a single %s, which is only written if there is
+something to write, so that brackets and spaces can be left out for entries without tags. Braces in an
+argument must be balanced. If the pattern doesn't include fields or the stack, they are added to the end of
+the entry as they are for the other formatters.
+An error is returned for unknown directives or a bad argument.
+*/
+func NewTemplateFormatter(pattern string, opts TemplateOptions) (LogFormatter, error) {
+	if opts.InfoLabel == "" {
+		opts.InfoLabel = "INF"
+	}
+	if opts.DebugLabel == "" {
+		opts.DebugLabel = "DBG"
+	}
+	if opts.TagSeparator == "" {
+		opts.TagSeparator = ", "
+	}
+
+	segments, hasFields, hasStack, err := compileTemplate(pattern, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(debug bool, tags []string, t time.Time, format string, args ...interface{}) string {
+		format, args, entry := SplitEntry(format, args)
+
+		e := &templateEntry{
+			debug:   debug,
+			tags:    tags,
+			time:    t,
+			message: fmt.Sprintf(format, args...),
+			entry:   entry,
+		}
+
+		b := &strings.Builder{}
+		for _, segment := range segments {
+			segment(b, e)
+		}
+
+		if entry != nil {
+			if !hasFields {
+				b.WriteString(fmt.Sprint(entry.Fields))
+			}
+			if !hasStack {
+				b.WriteString(stackString(entry))
+			}
+		}
+
+		return b.String()
+	}, nil
+}
+
+// compileTemplate splits the pattern into segments, and reports whether it includes the fields and stack
+func compileTemplate(pattern string, opts TemplateOptions) ([]templateSegment, bool, bool, error) {
+	var segments []templateSegment
+	var hasFields, hasStack bool
+	literal := &strings.Builder{}
+
+	flush := func() {
+		if literal.Len() == 0 {
+			return
+		}
+		s := literal.String()
+		segments = append(segments, func(b *strings.Builder, e *templateEntry) {
+			b.WriteString(s)
+		})
+		literal.Reset()
+	}
+
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		if c != '%' {
+			literal.WriteByte(c)
+			continue
+		}
+
+		if i+1 < len(pattern) && pattern[i+1] == '%' {
+			literal.WriteByte('%')
+			i++
+			continue
+		}
+
+		if i+1 >= len(pattern) || pattern[i+1] != '{' {
+			return nil, false, false, fmt.Errorf("invalid template at %d: expected %%{ or %%%%", i)
+		}
+
+		end := matchingBrace(pattern[i+1:]) + 1
+		if end == 0 {
+			return nil, false, false, fmt.Errorf("invalid template at %d: missing }", i)
+		}
+
+		directive := pattern[i+2 : i+end]
+		i += end
+
+		name, arg, hasArg := strings.Cut(directive, ":")
+		segment, err := templateDirective(name, arg, hasArg, opts)
+		if err != nil {
+			return nil, false, false, err
+		}
+
+		switch name {
+		case "fields":
+			hasFields = true
+		case "stack":
+			hasStack = true
+		}
+
+		flush()
+		segments = append(segments, segment)
+	}
+
+	flush()
+	return segments, hasFields, hasStack, nil
+}
+
+// matchingBrace returns the index of the } that closes the { at the start of s, braces inside an argument
+// are allowed as long as they are balanced. -1 is returned if there is no closing brace.
+func matchingBrace(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// templateDirective returns the segment for a directive
+func templateDirective(name string, arg string, hasArg bool, opts TemplateOptions) (templateSegment, error) {
+	switch name {
+	case "time":
+		timeFormat := opts.Time.orDefault()
+		if hasArg {
+			timeFormat = TimeLayout(arg, false)
+		}
+		return func(b *strings.Builder, e *templateEntry) {
+			b.WriteString(timeFormat(e.time))
+		}, nil
+	case "level":
+		if hasArg {
+			return nil, fmt.Errorf("invalid template: %%{level} doesn't take an argument")
+		}
+		return func(b *strings.Builder, e *templateEntry) {
+			if e.debug {
+				b.WriteString(opts.DebugLabel)
+			} else {
+				b.WriteString(opts.InfoLabel)
+			}
+		}, nil
+	case "msg":
+		if hasArg {
+			return nil, fmt.Errorf("invalid template: %%{msg} doesn't take an argument")
+		}
+		return func(b *strings.Builder, e *templateEntry) {
+			b.WriteString(e.message)
+		}, nil
+	}
+
+	var value func(e *templateEntry) string
+	switch name {
+	case "tags":
+		value = func(e *templateEntry) string {
+			return strings.Join(e.tags, opts.TagSeparator)
+		}
+	case "fields":
+		value = func(e *templateEntry) string {
+			if e.entry == nil {
+				return ""
+			}
+			return e.entry.Fields.String()
+		}
+	case "caller":
+		value = func(e *templateEntry) string {
+			if e.entry == nil || e.entry.Caller == nil {
+				return ""
+			}
+			return e.entry.Caller.String()
+		}
+	case "stack":
+		value = func(e *templateEntry) string {
+			if e.entry == nil {
+				return ""
+			}
+			return stackString(e.entry)
+		}
+	default:
+		return nil, fmt.Errorf("invalid template: unknown directive %%{%s}", name)
+	}
+
+	if !hasArg {
+		return func(b *strings.Builder, e *templateEntry) {
+			b.WriteString(value(e))
+		}, nil
+	}
+
+	if strings.Count(arg, "%s") != 1 || strings.Count(arg, "%") != 1 {
+		return nil, fmt.Errorf("invalid template: the argument for %%{%s} must contain a single %%s", name)
+	}
+	prefix, suffix, _ := strings.Cut(arg, "%s")
+
+	return func(b *strings.Builder, e *templateEntry) {
+		v := value(e)
+		if v == "" {
+			return
+		}
+		b.WriteString(prefix)
+		b.WriteString(v)
+		b.WriteString(suffix)
+	}, nil
+}
@@ -0,0 +1,71 @@
+package lg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateFormatter(t *testing.T) {
+	now := time.Date(2024, 1, 2, 15, 4, 5, 123000000, time.UTC)
+
+	formatter, err := NewTemplateFormatter("%{time:2006-01-02T15:04:05} [%{level}] %{tags:[%s] }%{msg}", TemplateOptions{})
+	require.NoError(t, err)
+
+	require.Equal(t, "2024-01-02T15:04:05 [INF] hello world", formatter(false, nil, now, "hello %s", "world"))
+	require.Equal(t, "2024-01-02T15:04:05 [DBG] [red, blue] hello", formatter(true, []string{"red", "blue"}, now, "hello"))
+
+	// fields and stacks are added to the end when the pattern doesn't include them
+	entry := &Entry{
+		Time:   now,
+		Format: "hello",
+		Fields: Fields{String("k", "v")},
+		Stack:  []Caller{{Function: "main.main", File: "/src/main.go", Line: 20}},
+	}
+	require.Equal(t, "2024-01-02T15:04:05 [INF] hello k=v\n\tmain.main\n\t\t/src/main.go:20", entry.FormatWith(formatter))
+
+	formatter, err = NewTemplateFormatter("%{level}|%{tags}|%{caller:(%s) }%{msg}%{fields: {%s}}%{stack}|100%%|%{time}", TemplateOptions{
+		InfoLabel:    "info",
+		DebugLabel:   "debug",
+		TagSeparator: "/",
+	})
+	require.NoError(t, err)
+
+	entry.Debug = true
+	entry.Tags = []string{"a", "b"}
+	entry.Caller = &Caller{File: "/src/server/main.go", Line: 12}
+	require.Equal(t, "debug|a/b|(server/main.go:12) hello {k=v}\n\tmain.main\n\t\t/src/main.go:20|100%|Jan  2 15:04:05.123",
+		entry.FormatWith(formatter))
+	require.Equal(t, "info||hello|100%|Jan  2 15:04:05.123", formatter(false, nil, now, "hello"))
+}
+
+func TestTemplateFormatterErrors(t *testing.T) {
+	for _, pattern := range []string{
+		"%{unknown}",
+		"%{msg",
+		"%s",
+		"trailing %",
+		"%{level:x}",
+		"%{msg:x}",
+		"%{tags:no verb}",
+		"%{tags:%s %s}",
+		"%{tags:%d}",
+		"%{fields:{%s}",
+	} {
+		_, err := NewTemplateFormatter(pattern, TemplateOptions{})
+		require.Error(t, err, pattern)
+	}
+}
+
+func BenchmarkTemplateFormatter(b *testing.B) {
+	formatter, _ := NewTemplateFormatter("%{time} [%{level}] %{tags:[%s] }%{msg}", TemplateOptions{})
+	now := time.Now()
+	tags := []string{"red", "blue"}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		formatter(false, tags, now, "hello %s", "world")
+	}
+}
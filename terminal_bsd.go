@@ -0,0 +1,19 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+
+package lg
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// IsTerminal returns true if the file is a terminal, it asks the kernel for the terminal settings with ioctl
+func IsTerminal(f *os.File) bool {
+	if f == nil {
+		return false
+	}
+	var termios syscall.Termios
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), syscall.TIOCGETA, uintptr(unsafe.Pointer(&termios)))
+	return errno == 0
+}
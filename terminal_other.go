@@ -0,0 +1,12 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+
+package lg
+
+import (
+	"os"
+)
+
+// IsTerminal always returns false on platforms without terminal detection
+func IsTerminal(f *os.File) bool {
+	return false
+}
@@ -0,0 +1,49 @@
+package lg
+
+import (
+	"strconv"
+	"time"
+)
+
+// TimeFormatter converts an entry's time to the string written by a formatter
+type TimeFormatter func(t time.Time) string
+
+// DefaultTimeFormat is used by FullFormat, SimpleFormat and ColorFormat, it is time.StampMilli in local time
+var DefaultTimeFormat = TimeLayout(time.StampMilli, false)
+
+// rfc3339Nano is the default for JSONFormat and LogfmtFormat
+var rfc3339Nano = TimeLayout(time.RFC3339Nano, false)
+
+// TimeLayout returns a TimeFormatter that formats times with the layout, in UTC if utc is true
+// and in local time otherwise
+func TimeLayout(layout string, utc bool) TimeFormatter {
+	if utc {
+		return func(t time.Time) string {
+			return t.UTC().Format(layout)
+		}
+	}
+	return func(t time.Time) string {
+		return t.Format(layout)
+	}
+}
+
+// EpochMillis formats times as the number of milliseconds since the Unix epoch
+func EpochMillis(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10)
+}
+
+// ElapsedSince returns a TimeFormatter that formats times as the duration since start, to the millisecond,
+// like "1m2.345s"
+func ElapsedSince(start time.Time) TimeFormatter {
+	return func(t time.Time) string {
+		return t.Sub(start).Truncate(time.Millisecond).String()
+	}
+}
+
+// orDefault returns the time formatter, or DefaultTimeFormat if it is nil
+func (tf TimeFormatter) orDefault() TimeFormatter {
+	if tf == nil {
+		return DefaultTimeFormat
+	}
+	return tf
+}
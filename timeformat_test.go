@@ -0,0 +1,97 @@
+package lg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeFormatters(t *testing.T) {
+	zone := time.FixedZone("test", -5*60*60)
+	now := time.Date(2024, 1, 2, 10, 4, 5, 123456789, zone)
+
+	require.Equal(t, "2024-01-02T10:04:05-05:00", TimeLayout(time.RFC3339, false)(now))
+	require.Equal(t, "2024-01-02T15:04:05Z", TimeLayout(time.RFC3339, true)(now))
+	require.Equal(t, "1704207845123", EpochMillis(now))
+	require.Equal(t, "1m2.345s", ElapsedSince(now)(now.Add(62345678*time.Microsecond)))
+	require.Equal(t, "0s", ElapsedSince(now)(now))
+	require.Equal(t, now.Format(time.StampMilli), TimeFormatter(nil).orDefault()(now))
+}
+
+func TestFormattersWithTime(t *testing.T) {
+	now := time.Date(2024, 1, 2, 15, 4, 5, 123000000, time.UTC)
+	utc := TimeLayout("2006-01-02 15:04:05.000 MST", true)
+
+	require.Equal(t, "2024-01-02 15:04:05.123 UTC [DBG] [red] hello", NewFullFormatter(utc)(true, []string{"red"}, now, "hello"))
+	require.Equal(t, "2024-01-02 15:04:05.123 UTC [INF] hello", NewSimpleFormatter(utc)(false, []string{"red"}, now, "hello"))
+	require.Equal(t, FullFormat(false, nil, now, "x"), NewFullFormatter(nil)(false, nil, now, "x"))
+	require.Equal(t, SimpleFormat(false, nil, now, "x"), NewSimpleFormatter(nil)(false, nil, now, "x"))
+	require.Equal(t, ColorFormat(false, nil, now, "x"), NewColorFormatter(nil)(false, nil, now, "x"))
+	require.Equal(t, "\x1b[90m1704207845123\x1b[0m \x1b[32m[INF]\x1b[0m x", NewColorFormatter(EpochMillis)(false, nil, now, "x"))
+
+	// a percent in the time doesn't break the format
+	percent := TimeLayout("100% 15:04", false)
+	require.Equal(t, "100% 15:04 [INF] done 1", NewFullFormatter(percent)(false, nil, now, "done %d", 1))
+	require.Equal(t, "100% 15:04 [INF] done 1", NewSimpleFormatter(percent)(false, nil, now, "done %d", 1))
+
+	require.Equal(t, `ts=1704207845123 level=info msg=x`, NewLogfmtFormatter(EpochMillis)(false, nil, now, "x"))
+	require.Equal(t, `ts="100% 15:04" level=info msg=x`, NewLogfmtFormatter(percent)(false, nil, now, "x"))
+	require.Equal(t, LogfmtFormat(false, nil, now, "x"), NewLogfmtFormatter(nil)(false, nil, now, "x"))
+
+	template, err := NewTemplateFormatter("%{time} %{time:15:04} %{msg}", TemplateOptions{Time: EpochMillis})
+	require.NoError(t, err)
+	require.Equal(t, "1704207845123 15:04 x", template(false, nil, now, "x"))
+}
+
+func TestJSONTime(t *testing.T) {
+	now := time.Date(2024, 1, 2, 15, 4, 5, 123000000, time.UTC)
+
+	opts := JSONOptions{Time: EpochMillis}
+	line := NewJSONFormatter(opts)(false, nil, now, "x")
+	require.Equal(t, `{"time":"1704207845123","level":"info","tags":[],"msg":"x"}`, line)
+
+	entry, err := NewJSONParser(opts)([]byte(line))
+	require.NoError(t, err)
+	require.True(t, entry.Time.Equal(now))
+
+	opts.Time = TimeLayout(time.RFC3339, true)
+	entry, err = NewJSONParser(opts)([]byte(NewJSONFormatter(opts)(false, nil, now, "x")))
+	require.NoError(t, err)
+	require.True(t, entry.Time.Equal(now.Truncate(time.Second)))
+
+	opts.Time = TimeLayout(time.Kitchen, false)
+	entry, err = NewJSONParser(opts)([]byte(NewJSONFormatter(opts)(false, nil, now, "x")))
+	require.NoError(t, err)
+	require.True(t, entry.Time.IsZero())
+
+	_, err = ParseJSON([]byte(`{"time":"1704207845123"}`))
+	require.Error(t, err)
+}
+
+func TestSetClock(t *testing.T) {
+	a := &EntryArrayAppender{}
+	logger := NewLoggerWithEntryAppender(a.Append)
+
+	now := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	logger.SetClock(func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	})
+
+	logger.Printf("one")
+	logger.WithTags("red").Printf("two")
+	require.Equal(t, time.Date(2024, 1, 2, 15, 4, 6, 0, time.UTC), a.Entries[0].Time)
+	require.Equal(t, time.Date(2024, 1, 2, 15, 4, 7, 0, time.UTC), a.Entries[1].Time)
+
+	appender := &ArrayAppender{}
+	logger.Configure(NewSimpleFormatter(TimeLayout(time.RFC3339, true)), appender.Log)
+	logger.Printf("three")
+	require.Equal(t, []string{"2024-01-02T15:04:08Z [INF] three"}, appender.Entries)
+
+	logger.SetClock(nil)
+	before := time.Now()
+	logger.ConfigureEntryAppender(a.Append)
+	logger.Printf("four")
+	require.False(t, a.Entries[2].Time.Before(before))
+}